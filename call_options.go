@@ -0,0 +1,113 @@
+package goclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// CallOption overrides the client behaviour for a single call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout      time.Duration
+	middlewares  []Middleware
+	skipRetry    bool
+	rateLimitKey string
+}
+
+type callOptionsKey struct{}
+
+// WithTimeout bounds the whole call, retries included. The timeout covers
+// reading the response body, and is released once the body is closed.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(options *callOptions) {
+		options.timeout = timeout
+	}
+}
+
+// WithCallMiddlewares adds middlewares running after the client ones, right
+// before the requester, so they apply to every retry of the call.
+func WithCallMiddlewares(middlewares ...Middleware) CallOption {
+	return func(options *callOptions) {
+		options.middlewares = append(options.middlewares, middlewares...)
+	}
+}
+
+// WithSkipRetry makes the retry middleware send the request only once.
+func WithSkipRetry() CallOption {
+	return func(options *callOptions) {
+		options.skipRetry = true
+	}
+}
+
+// WithRateLimitKey makes keyed rate limit middlewares count the call against
+// key instead of their default key.
+func WithRateLimitKey(key string) CallOption {
+	return func(options *callOptions) {
+		options.rateLimitKey = key
+	}
+}
+
+// WithCallOptions returns a context carrying call options, applied to the
+// requests made with it on top of the options already carried by ctx.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	options := getCallOptions(ctx)
+	options.middlewares = slices.Clone(options.middlewares)
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return context.WithValue(ctx, callOptionsKey{}, options)
+}
+
+// SkipRetry reports whether ctx asks to skip retries, see WithSkipRetry.
+func SkipRetry(ctx context.Context) bool {
+	return getCallOptions(ctx).skipRetry
+}
+
+// RateLimitKey returns the rate limit key set by WithRateLimitKey, if any.
+func RateLimitKey(ctx context.Context) string {
+	return getCallOptions(ctx).rateLimitKey
+}
+
+func getCallOptions(ctx context.Context) callOptions {
+	options, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return options
+}
+
+// DoWithOptions sends the request like Do, with options overriding the
+// client behaviour for this call only.
+func (c *Client) DoWithOptions(req *http.Request, opts ...CallOption) (*http.Response, error) {
+	if len(opts) > 0 {
+		req = req.WithContext(WithCallOptions(req.Context(), opts...))
+	}
+
+	return c.Do(req)
+}
+
+// withTimeout applies the call timeout to req. The returned cancel func must
+// be called if the call fails, otherwise the response body releases the
+// timeout when closed.
+func withTimeout(req *http.Request, timeout time.Duration) (*http.Request, context.CancelFunc) {
+	if timeout <= 0 {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// cancelOnClose releases the call timeout once the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
@@ -0,0 +1,189 @@
+package goclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCallOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		ctx              func() context.Context
+		wantSkipRetry    bool
+		wantRateLimitKey string
+	}{
+		{
+			name:             "happy flow: no options",
+			ctx:              context.Background,
+			wantSkipRetry:    false,
+			wantRateLimitKey: "",
+		},
+		{
+			name: "happy flow: options set",
+			ctx: func() context.Context {
+				return WithCallOptions(context.Background(), WithSkipRetry(), WithRateLimitKey("tenant-a"))
+			},
+			wantSkipRetry:    true,
+			wantRateLimitKey: "tenant-a",
+		},
+		{
+			name: "happy flow: options add up and override",
+			ctx: func() context.Context {
+				ctx := WithCallOptions(context.Background(), WithSkipRetry(), WithRateLimitKey("tenant-a"))
+				return WithCallOptions(ctx, WithRateLimitKey("tenant-b"))
+			},
+			wantSkipRetry:    true,
+			wantRateLimitKey: "tenant-b",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := test.ctx()
+			assert.Equal(t, test.wantSkipRetry, SkipRetry(ctx))
+			assert.Equal(t, test.wantRateLimitKey, RateLimitKey(ctx))
+		})
+	}
+}
+
+func TestWithCallOptions_DoesNotShareMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	middleware := func(f Requester) Requester { return f }
+	parent := WithCallOptions(context.Background(), WithCallMiddlewares(middleware))
+	_ = WithCallOptions(parent, WithCallMiddlewares(middleware))
+
+	assert.Len(t, getCallOptions(parent).middlewares, 1)
+}
+
+func TestClientDoWithOptions(t *testing.T) {
+	t.Parallel()
+
+	record := func(calls *[]string, name string) Middleware {
+		return func(f Requester) Requester {
+			return func(req *http.Request) (*http.Response, error) {
+				*calls = append(*calls, name)
+				return f(req)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		opts      func(calls *[]string) []CallOption
+		wantCalls []string
+		wantErr   error
+	}{
+		{
+			name:      "happy flow: no options",
+			opts:      func(calls *[]string) []CallOption { return nil },
+			wantCalls: []string{"client"},
+		},
+		{
+			name: "happy flow: call middlewares run after client middlewares",
+			opts: func(calls *[]string) []CallOption {
+				return []CallOption{WithCallMiddlewares(record(calls, "call 1"), record(calls, "call 2"))}
+			},
+			wantCalls: []string{"client", "call 1", "call 2"},
+		},
+		{
+			name:  "happy flow: within timeout",
+			delay: 0,
+			opts: func(calls *[]string) []CallOption {
+				return []CallOption{WithTimeout(time.Second)}
+			},
+			wantCalls: []string{"client"},
+		},
+		{
+			name:  "error flow: timeout exceeded",
+			delay: 200 * time.Millisecond,
+			opts: func(calls *[]string) []CallOption {
+				return []CallOption{WithTimeout(20 * time.Millisecond)}
+			},
+			wantCalls: []string{"client"},
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(test.delay):
+				case <-r.Context().Done():
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			calls := []string{}
+			client := NewClient(WithMiddlewares(record(&calls, "client")))
+
+			req, reqErr := http.NewRequest(http.MethodGet, server.URL, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := client.DoWithOptions(req, test.opts(&calls)...)
+			assert.Equal(t, test.wantCalls, calls)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			body, bodyErr := io.ReadAll(resp.Body)
+			assert.NoError(t, bodyErr)
+			assert.Equal(t, "ok", string(body))
+			assert.NoError(t, resp.Body.Close())
+		})
+	}
+}
+
+func TestClientDoWithOptions_TimeoutCoversBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	req, reqErr := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, reqErr)
+
+	resp, err := NewClient().DoWithOptions(req, WithTimeout(50*time.Millisecond))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.True(t, strings.HasPrefix("partial", string(body)))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCancelOnClose(t *testing.T) {
+	t.Parallel()
+
+	cancelled := false
+	body := &cancelOnClose{
+		ReadCloser: io.NopCloser(strings.NewReader("body")),
+		cancel:     func() { cancelled = true },
+	}
+
+	assert.NoError(t, body.Close())
+	assert.True(t, cancelled)
+}
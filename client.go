@@ -2,6 +2,9 @@ package goclient
 
 import (
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
 )
 
 type Requester func(*http.Request) (*http.Response, error)
@@ -11,6 +14,13 @@ type ResultValidator func(req *http.Request, resp *http.Response, err error) boo
 type Client struct {
 	middlewares []Middleware
 	requester   Requester
+	// parent, if set, sends the requests instead of requester, see With.
+	parent *Client
+
+	// chain is the requester wrapped by every middleware, built once and
+	// rebuilt by Use. mu serialises the builds.
+	mu    sync.Mutex
+	chain atomic.Pointer[Requester]
 }
 
 func NewClient(options ...ClientOption) *Client {
@@ -20,18 +30,113 @@ func NewClient(options ...ClientOption) *Client {
 		option(client)
 	}
 
-	if client.requester == nil {
+	if client.requester == nil && client.parent == nil {
 		client.requester = defaultRequester
 	}
 
+	client.build()
+
 	return client
 }
 
+// With returns a client sending its requests through c, e.g. the shared
+// client plus authentication for one tenant. Middlewares added by options
+// run before the ones of c, which are neither re-applied nor mutated, so
+// stateful middlewares such as rate limit queues stay shared. Middlewares
+// later added to c with Use apply to the derived client too. Per-call
+// middlewares run once, after the middlewares of c.
+func (c *Client) With(options ...ClientOption) *Client {
+	return NewClient(append([]ClientOption{withParent(c)}, options...)...)
+}
+
+// withParent sends the requests of the client through parent. WithRequester
+// overrides it.
+func withParent(parent *Client) ClientOption {
+	return func(client *Client) {
+		client.parent = parent
+		client.requester = nil
+	}
+}
+
+// send sends the request through the middleware chain, without the call
+// timeout handled by Do.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	return c.getChain()(req)
+}
+
+// Use appends middlewares to the client and rebuilds its chain, applying
+// every middleware again. Calls in flight finish with the previous chain.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.middlewares = append(slices.Clip(c.middlewares), middlewares...)
+	c.buildLocked()
+}
+
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	f := c.requester
+	options := getCallOptions(req.Context())
+
+	req, cancel := withTimeout(req, options.timeout)
+	resp, err := c.getChain()(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		cancel()
+		return resp, err
+	}
+
+	if options.timeout > 0 {
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+
+	return resp, nil
+}
+
+// getChain returns the middleware chain, building it for clients not
+// created by NewClient.
+func (c *Client) getChain() Requester {
+	if chain := c.chain.Load(); chain != nil {
+		return *chain
+	}
+
+	return c.build()
+}
+
+func (c *Client) build() Requester {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.buildLocked()
+}
+
+// buildLocked wraps the requester with every middleware. Must be called
+// with mu held.
+func (c *Client) buildLocked() Requester {
+	f := withCallMiddlewares(c.requester)
+	if c.parent != nil {
+		// the parent chain runs the call middlewares
+		f = c.parent.send
+	}
 	for i := len(c.middlewares) - 1; i >= 0; i-- {
 		f = c.middlewares[i](f)
 	}
 
-	return f(req)
+	c.chain.Store(&f)
+	return f
+}
+
+// withCallMiddlewares runs the middlewares of the call, if any, right before
+// the requester.
+func withCallMiddlewares(requester Requester) Requester {
+	return func(req *http.Request) (*http.Response, error) {
+		middlewares := getCallOptions(req.Context()).middlewares
+		if len(middlewares) == 0 {
+			return requester(req)
+		}
+
+		f := requester
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			f = middlewares[i](f)
+		}
+		return f(req)
+	}
 }
@@ -0,0 +1,95 @@
+package goclient
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func benchmarkMiddleware(f Requester) Requester {
+	return func(req *http.Request) (*http.Response, error) {
+		return f(req)
+	}
+}
+
+func benchmarkRequester(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func benchmarkMiddlewares(n int) []Middleware {
+	middlewares := make([]Middleware, n)
+	for i := range middlewares {
+		middlewares[i] = benchmarkMiddleware
+	}
+	return middlewares
+}
+
+// composePerCall is how Do used to build the chain on every call, kept as a
+// baseline.
+func composePerCall(middlewares []Middleware, requester Requester, req *http.Request) (*http.Response, error) {
+	f := requester
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		f = middlewares[i](f)
+	}
+
+	return f(req)
+}
+
+func BenchmarkClientDo(b *testing.B) {
+	for _, n := range []int{0, 1, 5, 20} {
+		b.Run(fmt.Sprintf("%d middlewares", n), func(b *testing.B) {
+			client := NewClient(WithRequester(benchmarkRequester), WithMiddlewares(benchmarkMiddlewares(n)...))
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				client.Do(req)
+			}
+		})
+	}
+}
+
+func BenchmarkComposePerCall(b *testing.B) {
+	for _, n := range []int{0, 1, 5, 20} {
+		b.Run(fmt.Sprintf("%d middlewares", n), func(b *testing.B) {
+			middlewares := benchmarkMiddlewares(n)
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				composePerCall(middlewares, benchmarkRequester, req)
+			}
+		})
+	}
+}
+
+func BenchmarkClientDoParallel(b *testing.B) {
+	client := NewClient(WithRequester(benchmarkRequester), WithMiddlewares(benchmarkMiddlewares(5)...))
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		for pb.Next() {
+			client.Do(req)
+		}
+	})
+}
+
+// TestClientDo_Allocations does not run in parallel, as allocations are
+// counted process-wide.
+func TestClientDo_Allocations(t *testing.T) {
+	client := NewClient(WithRequester(benchmarkRequester), WithMiddlewares(benchmarkMiddlewares(20)...))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	perCall := testing.AllocsPerRun(100, func() {
+		composePerCall(client.middlewares, benchmarkRequester, req)
+	})
+	precomposed := testing.AllocsPerRun(100, func() {
+		client.Do(req)
+	})
+
+	// the precomposed chain allocates no closure per middleware
+	if precomposed >= perCall {
+		t.Errorf("Do allocates %v times per call, want fewer than %v", precomposed, perCall)
+	}
+}
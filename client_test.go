@@ -119,3 +119,267 @@ func TestClientDo(t *testing.T) {
 		})
 	}
 }
+
+func TestClientChain(t *testing.T) {
+	t.Parallel()
+
+	okRequester := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	tests := []struct {
+		name          string
+		client        func(middleware Middleware) *Client
+		use           bool
+		wantBuilds    int
+		wantCallCount int
+	}{
+		{
+			name: "happy flow: chain built once by NewClient",
+			client: func(middleware Middleware) *Client {
+				return NewClient(WithRequester(okRequester), WithMiddlewares(middleware))
+			},
+			wantBuilds:    1,
+			wantCallCount: 3,
+		},
+		{
+			name: "happy flow: chain built lazily for client literal",
+			client: func(middleware Middleware) *Client {
+				return &Client{requester: okRequester, middlewares: []Middleware{middleware}}
+			},
+			wantBuilds:    1,
+			wantCallCount: 3,
+		},
+		{
+			name: "happy flow: Use rebuilds the chain",
+			client: func(middleware Middleware) *Client {
+				return NewClient(WithRequester(okRequester), WithMiddlewares(middleware))
+			},
+			use:           true,
+			wantBuilds:    3,
+			wantCallCount: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			builds, callCount := 0, 0
+			counting := func(f Requester) Requester {
+				builds++
+				return func(req *http.Request) (*http.Response, error) {
+					callCount++
+					return f(req)
+				}
+			}
+
+			client := test.client(counting)
+			if test.use {
+				client.Use(counting)
+			}
+
+			for range 3 {
+				req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+				assert.NoError(t, reqErr)
+
+				resp, err := client.Do(req)
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+
+			assert.Equal(t, test.wantBuilds, builds)
+			assert.Equal(t, test.wantCallCount, callCount)
+		})
+	}
+}
+
+func TestClientUse_DoesNotMutateSharedMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	noop := func(f Requester) Requester { return f }
+	middlewares := make([]Middleware, 1, 4)
+	middlewares[0] = noop
+
+	client := NewClient(WithMiddlewares(middlewares[:1]...))
+	client.Use(noop)
+
+	assert.Len(t, client.middlewares, 2)
+	assert.Len(t, middlewares, 1)
+}
+
+func TestClientWith(t *testing.T) {
+	t.Parallel()
+
+	type recorder struct {
+		builds int
+		calls  []string
+	}
+	record := func(rec *recorder, name string) Middleware {
+		return func(f Requester) Requester {
+			rec.builds++
+			return func(req *http.Request) (*http.Response, error) {
+				rec.calls = append(rec.calls, name)
+				return f(req)
+			}
+		}
+	}
+	okRequester := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	tests := []struct {
+		name            string
+		derive          func(parent *Client, rec *recorder) *Client
+		wantDerivedCall []string
+		wantBuilds      int
+	}{
+		{
+			name: "happy flow: derived middlewares run before the parent ones",
+			derive: func(parent *Client, rec *recorder) *Client {
+				return parent.With(WithMiddlewares(record(rec, "tenant auth")))
+			},
+			wantDerivedCall: []string{"tenant auth", "parent"},
+			wantBuilds:      2,
+		},
+		{
+			name: "happy flow: no options",
+			derive: func(parent *Client, rec *recorder) *Client {
+				return parent.With()
+			},
+			wantDerivedCall: []string{"parent"},
+			wantBuilds:      1,
+		},
+		{
+			name: "happy flow: derived from a derived client",
+			derive: func(parent *Client, rec *recorder) *Client {
+				return parent.With(WithMiddlewares(record(rec, "tenant"))).With(WithMiddlewares(record(rec, "user")))
+			},
+			wantDerivedCall: []string{"user", "tenant", "parent"},
+			wantBuilds:      3,
+		},
+		{
+			name: "happy flow: Use on the derived client leaves the parent alone",
+			derive: func(parent *Client, rec *recorder) *Client {
+				derived := parent.With()
+				derived.Use(record(rec, "derived"))
+				return derived
+			},
+			wantDerivedCall: []string{"derived", "parent"},
+			wantBuilds:      2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &recorder{}
+			parent := NewClient(WithRequester(okRequester), WithMiddlewares(record(rec, "parent")))
+			derived := test.derive(parent, rec)
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			_, err := derived.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantDerivedCall, rec.calls)
+
+			rec.calls = nil
+			_, err = parent.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"parent"}, rec.calls)
+			assert.Len(t, parent.middlewares, 1)
+
+			// the parent middleware is applied once, whatever was derived
+			assert.Equal(t, test.wantBuilds, rec.builds)
+		})
+	}
+}
+
+func TestClientWith_CallMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	okRequester := func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	tests := []struct {
+		name      string
+		derive    func(parent *Client, record func(name string) Middleware) *Client
+		wantCalls []string
+	}{
+		{
+			name: "happy flow: call middlewares run once, after every client middleware",
+			derive: func(parent *Client, record func(name string) Middleware) *Client {
+				return parent.With(WithMiddlewares(record("derived")))
+			},
+			wantCalls: []string{"derived", "parent", "call"},
+		},
+		{
+			name: "happy flow: derived from a derived client",
+			derive: func(parent *Client, record func(name string) Middleware) *Client {
+				return parent.With().With(WithMiddlewares(record("derived")))
+			},
+			wantCalls: []string{"derived", "parent", "call"},
+		},
+		{
+			name: "happy flow: own requester replaces the parent",
+			derive: func(parent *Client, record func(name string) Middleware) *Client {
+				return parent.With(WithRequester(okRequester))
+			},
+			wantCalls: []string{"call"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := []string{}
+			record := func(name string) Middleware {
+				return func(f Requester) Requester {
+					return func(req *http.Request) (*http.Response, error) {
+						calls = append(calls, name)
+						return f(req)
+					}
+				}
+			}
+			parent := NewClient(WithRequester(okRequester), WithMiddlewares(record("parent")))
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			_, err := test.derive(parent, record).DoWithOptions(req, WithCallMiddlewares(record("call")))
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantCalls, calls)
+		})
+	}
+}
+
+func TestClientWith_ParentUse(t *testing.T) {
+	t.Parallel()
+
+	calls := []string{}
+	record := func(name string) Middleware {
+		return func(f Requester) Requester {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return f(req)
+			}
+		}
+	}
+
+	parent := NewClient(WithRequester(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	derived := parent.With(WithMiddlewares(record("derived")))
+	parent.Use(record("parent"))
+
+	req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, reqErr)
+
+	_, err := derived.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"derived", "parent"}, calls)
+}
@@ -0,0 +1,230 @@
+package goclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// handlerRemoteAddr is the remote address seen by handlers, taken from the
+// documentation range like the one of httptest.NewRequest.
+const handlerRemoteAddr = "192.0.2.1:1234"
+
+// HandlerRequester serves requests with h in process, without a network
+// listener. The response is returned as soon as the handler writes its
+// header, and its body streams what the handler writes until it returns.
+// Trailers are available once the body has been read to EOF. Closing the
+// body early cancels the request context seen by the handler.
+func HandlerRequester(h http.Handler) Requester {
+	return func(req *http.Request) (*http.Response, error) {
+		ctx, cancel := context.WithCancel(req.Context())
+		serverReq := newHandlerRequest(req.WithContext(ctx))
+
+		reader, writer := io.Pipe()
+		w := &handlerResponseWriter{
+			req:       req,
+			header:    http.Header{},
+			body:      writer,
+			committed: make(chan struct{}),
+			discard:   req.Method == http.MethodHead,
+		}
+		w.resp = &http.Response{
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Body:       &handlerResponseBody{PipeReader: reader, cancel: cancel},
+			Request:    req,
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- w.serve(h, serverReq)
+		}()
+
+		select {
+		case <-w.committed:
+			return w.resp, nil
+		case err := <-done:
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// the handler returned without committing, which serve does
+			<-w.committed
+			return w.resp, nil
+		case <-req.Context().Done():
+			reader.CloseWithError(context.Cause(req.Context()))
+			cancel()
+			return nil, context.Cause(req.Context())
+		}
+	}
+}
+
+// newHandlerRequest turns an outgoing request into the one a server would
+// hand to its handler.
+func newHandlerRequest(req *http.Request) *http.Request {
+	serverReq := req.Clone(req.Context())
+	if serverReq.Body == nil {
+		serverReq.Body = http.NoBody
+	}
+	if serverReq.Host == "" && serverReq.URL != nil {
+		serverReq.Host = serverReq.URL.Host
+	}
+	if serverReq.URL != nil {
+		serverReq.RequestURI = serverReq.URL.RequestURI()
+	}
+	serverReq.RemoteAddr = handlerRemoteAddr
+	serverReq.Proto, serverReq.ProtoMajor, serverReq.ProtoMinor = "HTTP/1.1", 1, 1
+
+	return serverReq
+}
+
+// handlerResponseWriter is the http.ResponseWriter given to the handler.
+type handlerResponseWriter struct {
+	req    *http.Request
+	header http.Header
+	body   *io.PipeWriter
+	resp   *http.Response
+
+	mu sync.Mutex
+	// committed is closed once the status and header have been sent.
+	committed   chan struct{}
+	isCommitted bool
+	// declared lists the trailers announced in the Trailer header.
+	declared []string
+	discard  bool
+}
+
+func (w *handlerResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *handlerResponseWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.commit(code)
+}
+
+func (w *handlerResponseWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if !w.isCommitted {
+		if _, ok := w.header["Content-Type"]; !ok && len(p) > 0 {
+			w.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		w.commit(http.StatusOK)
+	}
+	w.mu.Unlock()
+
+	if w.discard {
+		return len(p), nil
+	}
+
+	return w.body.Write(p)
+}
+
+// Flush sends the header if it has not been sent yet. Written data is
+// never buffered.
+func (w *handlerResponseWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.commit(http.StatusOK)
+}
+
+// commit sends the status and a snapshot of the header, unless done
+// already. Informational statuses are ignored. Must be called with mu held.
+func (w *handlerResponseWriter) commit(code int) {
+	if w.isCommitted || (code >= 100 && code < 200) {
+		return
+	}
+	w.isCommitted = true
+
+	header := w.header.Clone()
+	for _, names := range header.Values("Trailer") {
+		for name := range strings.SplitSeq(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				w.declared = append(w.declared, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	header.Del("Trailer")
+
+	w.resp.StatusCode = code
+	w.resp.Status = fmt.Sprintf("%d %s", code, http.StatusText(code))
+	w.resp.Header = header
+	w.resp.ContentLength = -1
+	if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		w.resp.ContentLength = length
+	}
+	if len(w.declared) > 0 {
+		w.resp.Trailer = http.Header{}
+		for _, name := range w.declared {
+			w.resp.Trailer[name] = nil
+		}
+	}
+	close(w.committed)
+}
+
+// serve runs the handler, then sends the trailers and ends the body. It
+// returns an error if the handler panicked before sending its header.
+func (w *handlerResponseWriter) serve(h http.Handler, req *http.Request) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if err != nil {
+			w.body.CloseWithError(err)
+			if !w.isCommitted {
+				return
+			}
+			// the header is out, the caller sees the error reading the body
+			err = nil
+			return
+		}
+
+		w.commit(http.StatusOK)
+		w.setTrailers()
+		w.body.Close()
+	}()
+
+	h.ServeHTTP(w, req)
+	return nil
+}
+
+// setTrailers copies the trailers set by the handler to the response, before
+// the body reaches EOF. Must be called with mu held.
+func (w *handlerResponseWriter) setTrailers() {
+	for _, name := range w.declared {
+		if values, ok := w.header[name]; ok {
+			w.resp.Trailer[name] = values
+		}
+	}
+	for key, values := range w.header {
+		if name, ok := strings.CutPrefix(key, http.TrailerPrefix); ok {
+			if w.resp.Trailer == nil {
+				w.resp.Trailer = http.Header{}
+			}
+			w.resp.Trailer[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+}
+
+// handlerResponseBody cancels the handler request once closed.
+type handlerResponseBody struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (body *handlerResponseBody) Close() error {
+	body.cancel()
+	return body.PipeReader.Close()
+}
@@ -0,0 +1,281 @@
+package goclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerRequester(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		method      string
+		handler     http.HandlerFunc
+		wantStatus  int
+		wantHeader  http.Header
+		wantBody    string
+		wantLength  int64
+		wantTrailer http.Header
+	}{
+		{
+			name:   "happy flow: status, header and body",
+			method: http.MethodPost,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Length", "11")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":"1"}` + "\n"))
+			},
+			wantStatus: http.StatusCreated,
+			wantHeader: http.Header{"Content-Type": {"application/json"}, "Content-Length": {"11"}},
+			wantBody:   `{"id":"1"}` + "\n",
+			wantLength: 11,
+		},
+		{
+			name:   "happy flow: implicit 200 with sniffed content type",
+			method: http.MethodGet,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html></html>"))
+			},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			wantBody:   "<html></html>",
+			wantLength: -1,
+		},
+		{
+			name:       "happy flow: handler writing nothing",
+			method:     http.MethodGet,
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{},
+			wantBody:   "",
+			wantLength: -1,
+		},
+		{
+			name:   "happy flow: informational status ignored",
+			method: http.MethodGet,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusNoContent)
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: http.Header{},
+			wantLength: -1,
+		},
+		{
+			name:   "happy flow: HEAD discards the body",
+			method: http.MethodHead,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("ignored"))
+			},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Content-Type": {"text/plain"}},
+			wantBody:   "",
+			wantLength: -1,
+		},
+		{
+			name:   "happy flow: declared and prefixed trailers",
+			method: http.MethodGet,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "X-Checksum")
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("body"))
+				w.Header().Set("X-Checksum", "abc")
+				w.Header().Set(http.TrailerPrefix+"X-Late", "def")
+			},
+			wantStatus:  http.StatusOK,
+			wantHeader:  http.Header{"Content-Type": {"text/plain"}},
+			wantBody:    "body",
+			wantLength:  -1,
+			wantTrailer: http.Header{"X-Checksum": {"abc"}, "X-Late": {"def"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, reqErr := http.NewRequest(test.method, "http://example.com/items", nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := HandlerRequester(test.handler)(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, bodyErr := io.ReadAll(resp.Body)
+			assert.NoError(t, bodyErr)
+
+			assert.Equal(t, test.wantStatus, resp.StatusCode)
+			assert.Equal(t, http.StatusText(test.wantStatus), strings.SplitN(resp.Status, " ", 2)[1])
+			assert.Equal(t, test.wantHeader, resp.Header)
+			assert.Equal(t, test.wantBody, string(body))
+			assert.Equal(t, test.wantLength, resp.ContentLength)
+			assert.Equal(t, test.wantTrailer, resp.Trailer)
+			assert.Same(t, req, resp.Request)
+			assert.Equal(t, 1, resp.ProtoMajor)
+		})
+	}
+}
+
+func TestHandlerRequester_ServerRequest(t *testing.T) {
+	t.Parallel()
+
+	var got *http.Request
+	var gotBody string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	})
+
+	req, reqErr := http.NewRequest(http.MethodPost, "http://example.com/items?page=2", strings.NewReader("payload"))
+	assert.NoError(t, reqErr)
+
+	resp, err := HandlerRequester(handler)(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "/items?page=2", got.RequestURI)
+	assert.Equal(t, "example.com", got.Host)
+	assert.Equal(t, handlerRemoteAddr, got.RemoteAddr)
+	assert.Equal(t, "payload", gotBody)
+	assert.Empty(t, req.RequestURI)
+}
+
+func TestHandlerRequester_Streaming(t *testing.T) {
+	t.Parallel()
+
+	next := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-next
+		w.Write([]byte("second\n"))
+	})
+
+	req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, reqErr)
+
+	resp, err := HandlerRequester(handler)(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	// the first line arrives while the handler is still running
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "first\n", line)
+
+	close(next)
+	rest, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "second\n", string(rest))
+}
+
+func TestHandlerRequester_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error flow: panic before the header", func(t *testing.T) {
+		t.Parallel()
+
+		req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, reqErr)
+
+		resp, err := HandlerRequester(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))(req)
+		assert.Nil(t, resp)
+		assert.ErrorContains(t, err, "handler panicked: boom")
+	})
+
+	t.Run("error flow: panic after the header", func(t *testing.T) {
+		t.Parallel()
+
+		req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, reqErr)
+
+		resp, err := HandlerRequester(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			panic(http.ErrAbortHandler)
+		}))(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorContains(t, err, "handler panicked")
+	})
+
+	t.Run("error flow: request cancelled before the header", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, reqErr)
+
+		handlerDone := make(chan struct{})
+		resp, err := HandlerRequester(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(handlerDone)
+			<-r.Context().Done()
+		}))(req)
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		<-handlerDone
+	})
+
+	t.Run("happy flow: closing the body cancels the handler", func(t *testing.T) {
+		t.Parallel()
+
+		req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, reqErr)
+
+		writeErr := make(chan error)
+		resp, err := HandlerRequester(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			<-r.Context().Done()
+			_, err := w.Write([]byte("too late"))
+			writeErr <- err
+		}))(req)
+		assert.NoError(t, err)
+
+		assert.NoError(t, resp.Body.Close())
+		assert.True(t, errors.Is(<-writeErr, io.ErrClosedPipe))
+	})
+}
+
+func TestHandlerRequester_WithMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := NewClient(
+		WithRequester(HandlerRequester(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Write([]byte(r.Header.Get("Authorization")))
+		}))),
+		WithMiddlewares(func(f Requester) Requester {
+			return func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("Authorization", "Bearer token")
+				return f(req)
+			}
+		}),
+	)
+
+	resp, err := client.HTTPClient().Get("http://example.com")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", string(body))
+	assert.Equal(t, 1, calls)
+}
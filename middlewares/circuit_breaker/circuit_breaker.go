@@ -2,29 +2,86 @@ package circuitbreaker
 
 import (
 	"errors"
+	"math"
+	"math/rand/v2"
 	"sync"
 	"time"
 
 	"github.com/htchan/goclient"
 )
 
-// ErrCircuitOpen is returned when the circuit breaker is open and rejects the request.
+// ErrCircuitOpen is returned when the circuit breaker is open and rejects the
+// request. Rejections are reported as *OpenError, which matches ErrCircuitOpen
+// with errors.Is.
 var ErrCircuitOpen = errors.New("circuit breaker is open")
 
 // CircuitBreaker tracks the state of the circuit breaker.
 type CircuitBreaker struct {
 	mu sync.Mutex
 
+	name string
+	key  string
+
 	state            State
 	failureThreshold int
 	successThreshold int
 	recoverDuration  time.Duration
 	isFailure        goclient.ResultValidator
+	classify         Classifier
 
 	failureCount int
 	successCount int
 	lastFailure  time.Time
 
+	// mode is the manual override set by ForceOpen, ForceClosed and Disable.
+	mode Mode
+	// counts holds cumulative outcomes, reported by Snapshot.
+	counts Counts
+
+	// slowCallDuration is the latency at which a call counts as slow; slow
+	// call detection is disabled when slowCalls is nil.
+	slowCallDuration      time.Duration
+	slowCallRateThreshold float64
+	slowCalls             *slowCallWindow
+
+	// halfOpenMaxCalls caps the number of concurrent probes in half-open
+	// state; zero means unlimited.
+	halfOpenMaxCalls int
+	halfOpenCalls    int
+
+	// generation is bumped on every state transition so that probe slots
+	// reserved in an earlier half-open period are not released twice.
+	generation uint64
+
+	// recoverBackoff grows the open duration on every consecutive re-open;
+	// backoff is disabled when it is not greater than 1.
+	recoverBackoff     float64
+	maxRecoverDuration time.Duration
+	recoverJitter      float64
+	reopenCount        int
+	openDuration       time.Duration
+
+	// store, if set, persists the state across restarts and processes.
+	store             StateStore
+	storeSyncInterval time.Duration
+	onStoreError      func(error)
+	lastSync          time.Time
+	// changedAt is the time of the last state transition.
+	changedAt time.Time
+	// restoring suppresses saving while the state is loaded from the store.
+	restoring bool
+	// unsaved is set by a transition not saved yet. Saves happen once mu is
+	// released, one at a time under storeMu, so that store I/O never blocks
+	// requests.
+	unsaved bool
+	storeMu sync.Mutex
+
+	// fallback, if set, handles requests rejected by the breaker.
+	fallback FallbackFunc
+
+	// randFloat returns a number in [0, 1), injectable for testing.
+	randFloat func() float64
+
 	// now is a function that returns the current time, injectable for testing.
 	now func() time.Time
 
@@ -50,12 +107,41 @@ func WithOnStateChange(f func(from, to State)) Option {
 	}
 }
 
+// WithHalfOpenMaxCalls limits how many requests may be in flight while the
+// breaker is half-open. Requests beyond the limit are rejected with
+// ErrCircuitOpen until the outstanding probes complete. Zero or a negative
+// value lets every request through, which is the default.
+func WithHalfOpenMaxCalls(n int) Option {
+	if n < 0 {
+		n = 0
+	}
+
+	return func(breaker *CircuitBreaker) {
+		breaker.halfOpenMaxCalls = n
+	}
+}
+
+// WithRecoverBackoff multiplies the open duration by multiplier every time a
+// half-open probe fails and the circuit re-opens, up to maxDuration. jitter
+// (0 to 1) randomly spreads each duration by up to that fraction so that
+// breakers sharing an upstream do not probe it in lockstep; a jittered
+// duration never exceeds maxDuration. The duration is reset to
+// recoverDuration once the breaker closes.
+func WithRecoverBackoff(multiplier float64, maxDuration time.Duration, jitter float64) Option {
+	return func(breaker *CircuitBreaker) {
+		breaker.recoverBackoff = multiplier
+		breaker.maxRecoverDuration = maxDuration
+		breaker.recoverJitter = min(max(jitter, 0), 1)
+	}
+}
+
 // NewCircuitBreaker creates a new circuit breaker.
 //
 // failureThreshold: number of consecutive failures before opening the circuit.
 // successThreshold: number of consecutive successes in half-open state before closing.
 // recoverDuration: how long to wait in open state before transitioning to half-open.
-// isFailure: determines whether a request result counts as a failure.
+// isFailure: determines whether a request result counts as a failure. Requests
+// cancelled by the caller are ignored, see WithClassifier.
 func NewCircuitBreaker(
 	failureThreshold int,
 	successThreshold int,
@@ -76,7 +162,9 @@ func NewCircuitBreaker(
 		successThreshold: successThreshold,
 		recoverDuration:  recoverDuration,
 		isFailure:        isFailure,
+		classify:         IgnoreCancellation(isFailure),
 		now:              time.Now,
+		randFloat:        rand.Float64,
 		onStateChange:    func(from, to State) {},
 	}
 
@@ -84,6 +172,9 @@ func NewCircuitBreaker(
 		opt(breaker)
 	}
 
+	breaker.lastSync = breaker.now()
+	breaker.loadState()
+
 	return breaker
 }
 
@@ -91,9 +182,15 @@ func NewCircuitBreaker(
 // If the breaker is open and recoverDuration has elapsed, it transitions to half-open.
 func (breaker *CircuitBreaker) State() State {
 	breaker.mu.Lock()
-	defer breaker.mu.Unlock()
+	defer breaker.unlock()
 
-	if breaker.state == StateOpen && breaker.now().Sub(breaker.lastFailure) >= breaker.recoverDuration {
+	return breaker.currentState()
+}
+
+// currentState is State without locking. Must be called with mu held.
+func (breaker *CircuitBreaker) currentState() State {
+	if breaker.mode.automatic() && breaker.state == StateOpen &&
+		breaker.now().Sub(breaker.lastFailure) >= breaker.recoverTimeout() {
 		breaker.setState(StateHalfOpen)
 		breaker.successCount = 0
 		breaker.failureCount = 0
@@ -101,22 +198,165 @@ func (breaker *CircuitBreaker) State() State {
 	return breaker.state
 }
 
+// allowRequest reports whether a request may be sent. In half-open state it
+// reserves a probe slot, so the returned release func must be called once
+// the request has completed and its outcome has been recorded.
+func (breaker *CircuitBreaker) allowRequest() (release func(), err error) {
+	breaker.syncState()
+
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	state := breaker.currentState()
+	switch {
+	case breaker.mode == ModeForcedOpen:
+		breaker.counts.Rejections++
+		return nil, breaker.openError()
+	case breaker.mode == ModeForcedClosed || breaker.mode == ModeDisabled:
+		breaker.counts.Requests++
+		return func() {}, nil
+	}
+
+	switch state {
+	case StateOpen:
+		breaker.counts.Rejections++
+		return nil, breaker.openError()
+	case StateHalfOpen:
+		if breaker.halfOpenMaxCalls == 0 {
+			break
+		}
+		if breaker.halfOpenCalls >= breaker.halfOpenMaxCalls {
+			breaker.counts.Rejections++
+			return nil, breaker.openError()
+		}
+
+		breaker.counts.Requests++
+		breaker.halfOpenCalls++
+		generation := breaker.generation
+		return func() {
+			breaker.mu.Lock()
+			defer breaker.mu.Unlock()
+
+			if breaker.generation == generation && breaker.halfOpenCalls > 0 {
+				breaker.halfOpenCalls--
+			}
+		}, nil
+	}
+
+	breaker.counts.Requests++
+	return func() {}, nil
+}
+
 // setState transitions the breaker to a new state and fires the callback.
 // Must be called with mu held.
 func (breaker *CircuitBreaker) setState(to State) {
 	from := breaker.state
 	breaker.state = to
 	if from != to {
+		breaker.changedAt = breaker.now()
+		breaker.generation++
+		breaker.halfOpenCalls = 0
+		breaker.updateOpenDuration(from, to)
+		if breaker.slowCalls != nil {
+			breaker.slowCalls.reset()
+		}
+		breaker.saveState()
 		breaker.onStateChange(from, to)
 	}
 }
 
+// recoverTimeout returns how long the breaker stays open before probing.
+// Must be called with mu held.
+func (breaker *CircuitBreaker) recoverTimeout() time.Duration {
+	if breaker.openDuration > 0 {
+		return breaker.openDuration
+	}
+	return breaker.recoverDuration
+}
+
+// updateOpenDuration tracks consecutive re-opens and computes the backoff
+// for the next open period. Must be called with mu held.
+func (breaker *CircuitBreaker) updateOpenDuration(from, to State) {
+	switch {
+	case to == StateClosed:
+		breaker.reopenCount = 0
+		breaker.openDuration = 0
+		return
+	case to != StateOpen:
+		return
+	case from == StateHalfOpen:
+		breaker.reopenCount++
+	default:
+		breaker.reopenCount = 0
+	}
+
+	if breaker.recoverBackoff <= 1 {
+		return
+	}
+
+	maxDuration := breaker.maxRecoverDuration
+	if maxDuration <= 0 {
+		// unbounded backoff still has to fit in a time.Duration
+		maxDuration = math.MaxInt64 / 2
+	}
+	duration := float64(breaker.recoverDuration) * math.Pow(breaker.recoverBackoff, float64(breaker.reopenCount))
+	duration = min(duration, float64(maxDuration))
+	if breaker.recoverJitter > 0 && breaker.randFloat != nil {
+		// jitter spreads the capped duration, so that breakers stuck at the
+		// cap still probe at different times, but never beyond the cap
+		duration *= 1 + breaker.recoverJitter*(2*breaker.randFloat()-1)
+		duration = min(duration, float64(maxDuration))
+	}
+
+	breaker.openDuration = time.Duration(duration)
+}
+
+// recordCallDuration records how long a request took and opens the circuit
+// if slow calls dominate. It is a no-op unless slow call detection is enabled.
+func (breaker *CircuitBreaker) recordCallDuration(duration time.Duration) {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	if breaker.slowCalls == nil {
+		return
+	}
+
+	slow := duration >= breaker.slowCallDuration
+	if slow {
+		breaker.counts.SlowCalls++
+	}
+	if !breaker.mode.automatic() {
+		return
+	}
+
+	switch breaker.state {
+	case StateClosed:
+		breaker.slowCalls.record(slow)
+		if breaker.slowCalls.full() && breaker.slowCalls.rate() >= breaker.slowCallRateThreshold {
+			breaker.lastFailure = breaker.now()
+			breaker.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if slow {
+			// a slow probe means the upstream has not recovered yet
+			breaker.lastFailure = breaker.now()
+			breaker.setState(StateOpen)
+			breaker.successCount = 0
+		}
+	}
+}
+
 // recordSuccess records a successful request.
 func (breaker *CircuitBreaker) recordSuccess() {
 	breaker.mu.Lock()
-	defer breaker.mu.Unlock()
+	defer breaker.unlock()
 
+	breaker.counts.Successes++
 	breaker.failureCount = 0
+	if !breaker.mode.automatic() {
+		return
+	}
+
 	switch breaker.state {
 	case StateHalfOpen:
 		breaker.successCount++
@@ -132,11 +372,15 @@ func (breaker *CircuitBreaker) recordSuccess() {
 // recordFailure records a failed request.
 func (breaker *CircuitBreaker) recordFailure() {
 	breaker.mu.Lock()
-	defer breaker.mu.Unlock()
+	defer breaker.unlock()
 
+	breaker.counts.Failures++
 	breaker.successCount = 0
 	breaker.failureCount++
 	breaker.lastFailure = breaker.now()
+	if !breaker.mode.automatic() {
+		return
+	}
 
 	switch breaker.state {
 	case StateClosed:
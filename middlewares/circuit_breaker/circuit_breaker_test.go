@@ -82,7 +82,9 @@ func TestNewCircuitBreaker(t *testing.T) {
 			assert.Equal(t, tt.wantSuccessCount, breaker.successCount)
 			assert.Equal(t, tt.wantLastFailure, breaker.lastFailure)
 			assert.NotNil(t, breaker.now)
+			assert.NotNil(t, breaker.randFloat)
 			assert.NotNil(t, breaker.isFailure)
+			assert.NotNil(t, breaker.classify)
 			assert.NotNil(t, breaker.onStateChange)
 		})
 	}
@@ -383,3 +385,402 @@ func TestCircuitBreaker_setState(t *testing.T) {
 		})
 	}
 }
+
+func TestCircuitBreaker_recordCallDuration(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		breaker   *CircuitBreaker
+		durations []time.Duration
+		wantState State
+	}{
+		{
+			name: "disabled: never opens",
+			breaker: &CircuitBreaker{
+				state:         StateClosed,
+				now:           func() time.Time { return now },
+				onStateChange: func(from, to State) {},
+			},
+			durations: []time.Duration{time.Hour, time.Hour},
+			wantState: StateClosed,
+		},
+		{
+			name: "closed: stays closed before window is full",
+			breaker: &CircuitBreaker{
+				state:                 StateClosed,
+				recoverDuration:       5 * time.Second,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 0.5,
+				slowCalls:             newSlowCallWindow(3),
+				now:                   func() time.Time { return now },
+				onStateChange:         func(from, to State) {},
+			},
+			durations: []time.Duration{2 * time.Second, 2 * time.Second},
+			wantState: StateClosed,
+		},
+		{
+			name: "closed: stays closed below slow call rate",
+			breaker: &CircuitBreaker{
+				state:                 StateClosed,
+				recoverDuration:       5 * time.Second,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 0.5,
+				slowCalls:             newSlowCallWindow(3),
+				now:                   func() time.Time { return now },
+				onStateChange:         func(from, to State) {},
+			},
+			durations: []time.Duration{2 * time.Second, time.Millisecond, time.Millisecond},
+			wantState: StateClosed,
+		},
+		{
+			name: "closed: opens when slow call rate reaches threshold",
+			breaker: &CircuitBreaker{
+				state:                 StateClosed,
+				recoverDuration:       5 * time.Second,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 0.5,
+				slowCalls:             newSlowCallWindow(4),
+				now:                   func() time.Time { return now },
+				onStateChange:         func(from, to State) {},
+			},
+			durations: []time.Duration{time.Second, time.Millisecond, 3 * time.Second, time.Millisecond},
+			wantState: StateOpen,
+		},
+		{
+			name: "half-open: fast probe keeps half-open",
+			breaker: &CircuitBreaker{
+				state:                 StateHalfOpen,
+				recoverDuration:       5 * time.Second,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 0.5,
+				slowCalls:             newSlowCallWindow(4),
+				now:                   func() time.Time { return now },
+				onStateChange:         func(from, to State) {},
+			},
+			durations: []time.Duration{time.Millisecond},
+			wantState: StateHalfOpen,
+		},
+		{
+			name: "half-open: slow probe reopens",
+			breaker: &CircuitBreaker{
+				state:                 StateHalfOpen,
+				recoverDuration:       5 * time.Second,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 0.5,
+				slowCalls:             newSlowCallWindow(4),
+				now:                   func() time.Time { return now },
+				onStateChange:         func(from, to State) {},
+			},
+			durations: []time.Duration{2 * time.Second},
+			wantState: StateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, duration := range tt.durations {
+				tt.breaker.recordCallDuration(duration)
+			}
+			assert.Equal(t, tt.wantState, tt.breaker.State())
+		})
+	}
+}
+
+func TestCircuitBreaker_allowRequest(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name              string
+		breaker           *CircuitBreaker
+		requests          int
+		wantErrs          []error
+		wantHalfOpenCalls int
+	}{
+		{
+			name: "closed: allows every request",
+			breaker: &CircuitBreaker{
+				state:            StateClosed,
+				halfOpenMaxCalls: 1,
+				now:              func() time.Time { return now },
+				onStateChange:    func(from, to State) {},
+			},
+			requests:          2,
+			wantErrs:          []error{nil, nil},
+			wantHalfOpenCalls: 0,
+		},
+		{
+			name: "open: rejects every request",
+			breaker: &CircuitBreaker{
+				state:           StateOpen,
+				recoverDuration: 5 * time.Second,
+				lastFailure:     now,
+				now:             func() time.Time { return now },
+				onStateChange:   func(from, to State) {},
+			},
+			requests: 2,
+			wantErrs: []error{ErrCircuitOpen, ErrCircuitOpen},
+		},
+		{
+			name: "half-open: unlimited probes by default",
+			breaker: &CircuitBreaker{
+				state:         StateHalfOpen,
+				now:           func() time.Time { return now },
+				onStateChange: func(from, to State) {},
+			},
+			requests:          3,
+			wantErrs:          []error{nil, nil, nil},
+			wantHalfOpenCalls: 0,
+		},
+		{
+			name: "half-open: rejects probes beyond the limit",
+			breaker: &CircuitBreaker{
+				state:            StateHalfOpen,
+				halfOpenMaxCalls: 2,
+				now:              func() time.Time { return now },
+				onStateChange:    func(from, to State) {},
+			},
+			requests:          3,
+			wantErrs:          []error{nil, nil, ErrCircuitOpen},
+			wantHalfOpenCalls: 2,
+		},
+		{
+			name: "open: recovered breaker reserves a probe slot",
+			breaker: &CircuitBreaker{
+				state:            StateOpen,
+				recoverDuration:  5 * time.Second,
+				lastFailure:      now.Add(-6 * time.Second),
+				halfOpenMaxCalls: 1,
+				now:              func() time.Time { return now },
+				onStateChange:    func(from, to State) {},
+			},
+			requests:          2,
+			wantErrs:          []error{nil, ErrCircuitOpen},
+			wantHalfOpenCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var releases []func()
+			for i := range tt.requests {
+				release, err := tt.breaker.allowRequest()
+				assert.ErrorIs(t, err, tt.wantErrs[i])
+				if err == nil {
+					releases = append(releases, release)
+				}
+			}
+			assert.Equal(t, tt.wantHalfOpenCalls, tt.breaker.halfOpenCalls)
+
+			for _, release := range releases {
+				release()
+			}
+			assert.Equal(t, 0, tt.breaker.halfOpenCalls)
+		})
+	}
+}
+
+func TestCircuitBreaker_allowRequest_StaleRelease(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	breaker := &CircuitBreaker{
+		state:            StateHalfOpen,
+		failureThreshold: 1,
+		successThreshold: 1,
+		recoverDuration:  5 * time.Second,
+		halfOpenMaxCalls: 1,
+		now:              func() time.Time { return now },
+		onStateChange:    func(from, to State) {},
+	}
+
+	staleRelease, err := breaker.allowRequest()
+	assert.NoError(t, err)
+
+	// the probe fails and the breaker reopens, then recovers to half-open
+	breaker.recordFailure()
+	breaker.lastFailure = now.Add(-6 * time.Second)
+
+	release, err := breaker.allowRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, breaker.halfOpenCalls)
+
+	// releasing the probe from the previous half-open period keeps the new slot reserved
+	staleRelease()
+	assert.Equal(t, 1, breaker.halfOpenCalls)
+
+	release()
+	assert.Equal(t, 0, breaker.halfOpenCalls)
+}
+
+func TestCircuitBreaker_updateOpenDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		breaker          *CircuitBreaker
+		transitions      [][2]State
+		wantReopenCount  int
+		wantOpenDuration time.Duration
+	}{
+		{
+			name: "backoff disabled: keeps recover duration",
+			breaker: &CircuitBreaker{
+				recoverDuration: time.Second,
+			},
+			transitions:      [][2]State{{StateClosed, StateOpen}, {StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen}},
+			wantReopenCount:  1,
+			wantOpenDuration: 0,
+		},
+		{
+			name: "first open uses recover duration",
+			breaker: &CircuitBreaker{
+				recoverDuration: time.Second,
+				recoverBackoff:  2,
+			},
+			transitions:      [][2]State{{StateClosed, StateOpen}},
+			wantReopenCount:  0,
+			wantOpenDuration: time.Second,
+		},
+		{
+			name: "each re-open multiplies the duration",
+			breaker: &CircuitBreaker{
+				recoverDuration: time.Second,
+				recoverBackoff:  2,
+			},
+			transitions: [][2]State{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+			},
+			wantReopenCount:  2,
+			wantOpenDuration: 4 * time.Second,
+		},
+		{
+			name: "duration is capped",
+			breaker: &CircuitBreaker{
+				recoverDuration:    time.Second,
+				recoverBackoff:     10,
+				maxRecoverDuration: 30 * time.Second,
+			},
+			transitions: [][2]State{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+			},
+			wantReopenCount:  2,
+			wantOpenDuration: 30 * time.Second,
+		},
+		{
+			name: "jitter spreads the duration",
+			breaker: &CircuitBreaker{
+				recoverDuration: time.Second,
+				recoverBackoff:  2,
+				recoverJitter:   0.5,
+				randFloat:       func() float64 { return 1 },
+			},
+			transitions:      [][2]State{{StateClosed, StateOpen}, {StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen}},
+			wantReopenCount:  1,
+			wantOpenDuration: 3 * time.Second,
+		},
+		{
+			name: "jitter spreads the capped duration",
+			breaker: &CircuitBreaker{
+				recoverDuration:    time.Second,
+				recoverBackoff:     10,
+				maxRecoverDuration: 30 * time.Second,
+				recoverJitter:      0.5,
+				randFloat:          func() float64 { return 0 },
+			},
+			transitions: [][2]State{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+			},
+			wantReopenCount:  2,
+			wantOpenDuration: 15 * time.Second,
+		},
+		{
+			name: "jitter never exceeds the cap",
+			breaker: &CircuitBreaker{
+				recoverDuration:    time.Second,
+				recoverBackoff:     10,
+				maxRecoverDuration: 30 * time.Second,
+				recoverJitter:      0.5,
+				randFloat:          func() float64 { return 1 },
+			},
+			transitions: [][2]State{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+			},
+			wantReopenCount:  2,
+			wantOpenDuration: 30 * time.Second,
+		},
+		{
+			name: "closing resets the backoff",
+			breaker: &CircuitBreaker{
+				recoverDuration: time.Second,
+				recoverBackoff:  2,
+			},
+			transitions: [][2]State{
+				{StateClosed, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateOpen},
+				{StateOpen, StateHalfOpen}, {StateHalfOpen, StateClosed},
+			},
+			wantReopenCount:  0,
+			wantOpenDuration: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, transition := range tt.transitions {
+				tt.breaker.updateOpenDuration(transition[0], transition[1])
+			}
+			assert.Equal(t, tt.wantReopenCount, tt.breaker.reopenCount)
+			assert.Equal(t, tt.wantOpenDuration, tt.breaker.openDuration)
+		})
+	}
+}
+
+func TestCircuitBreaker_RecoverBackoff(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	breaker := NewCircuitBreaker(1, 1, time.Second, isServerError,
+		WithNowFunc(func() time.Time { return clock() }),
+		WithRecoverBackoff(2, 10*time.Second, 0),
+	)
+
+	wantOpenDurations := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for _, openDuration := range wantOpenDurations {
+		breaker.recordFailure()
+
+		clock = func() time.Time { return now.Add(openDuration - time.Millisecond) }
+		assert.Equal(t, StateOpen, breaker.State())
+
+		clock = func() time.Time { return now.Add(openDuration) }
+		assert.Equal(t, StateHalfOpen, breaker.State())
+		now = now.Add(openDuration)
+		clock = func() time.Time { return now }
+	}
+
+	breaker.recordSuccess()
+	assert.Equal(t, StateClosed, breaker.State())
+
+	breaker.recordFailure()
+	clock = func() time.Time { return now.Add(time.Second) }
+	assert.Equal(t, StateHalfOpen, breaker.State())
+}
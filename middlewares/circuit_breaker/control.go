@@ -0,0 +1,144 @@
+package circuitbreaker
+
+import "time"
+
+// Mode is a manual override of the circuit breaker behaviour.
+type Mode int
+
+const (
+	// ModeNormal lets the breaker open and close based on request outcomes.
+	ModeNormal Mode = iota
+	// ModeForcedOpen rejects every request until the breaker is reset.
+	ModeForcedOpen
+	// ModeForcedClosed lets every request through and never opens the circuit.
+	ModeForcedClosed
+	// ModeDisabled lets every request through but keeps tracking outcomes and
+	// state transitions, so the breaker can be observed without enforcing it.
+	ModeDisabled
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "normal"
+	case ModeForcedOpen:
+		return "forced-open"
+	case ModeForcedClosed:
+		return "forced-closed"
+	case ModeDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// automatic reports whether request outcomes drive state transitions.
+func (m Mode) automatic() bool {
+	return m == ModeNormal || m == ModeDisabled
+}
+
+// Counts holds the cumulative outcomes seen by a circuit breaker.
+type Counts struct {
+	// Requests is the number of requests let through by the breaker.
+	Requests uint64
+	// Successes and Failures count the recorded outcomes.
+	Successes uint64
+	Failures  uint64
+	// Ignored counts requests classified as OutcomeIgnored.
+	Ignored uint64
+	// SlowCalls counts calls over the slow call duration, if detection is enabled.
+	SlowCalls uint64
+	// Rejections is the number of requests rejected by the breaker.
+	Rejections uint64
+
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+}
+
+// Snapshot is a point-in-time view of a circuit breaker.
+type Snapshot struct {
+	State       State
+	Mode        Mode
+	Counts      Counts
+	LastFailure time.Time
+	// UntilHalfOpen is the time left before an open breaker lets probes
+	// through. It is zero unless the breaker is open in ModeNormal or
+	// ModeDisabled.
+	UntilHalfOpen time.Duration
+}
+
+// Snapshot returns the current state, counts and timing of the breaker.
+func (breaker *CircuitBreaker) Snapshot() Snapshot {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	counts := breaker.counts
+	counts.ConsecutiveSuccesses = breaker.successCount
+	counts.ConsecutiveFailures = breaker.failureCount
+
+	return Snapshot{
+		State:         breaker.currentState(),
+		Mode:          breaker.mode,
+		Counts:        counts,
+		LastFailure:   breaker.lastFailure,
+		UntilHalfOpen: breaker.untilHalfOpen(),
+	}
+}
+
+// untilHalfOpen returns the time left in open state. Must be called with mu held.
+func (breaker *CircuitBreaker) untilHalfOpen() time.Duration {
+	if breaker.state != StateOpen || !breaker.mode.automatic() {
+		return 0
+	}
+
+	return max(breaker.recoverTimeout()-breaker.now().Sub(breaker.lastFailure), 0)
+}
+
+// ForceOpen opens the circuit and keeps it open, rejecting every request,
+// until ForceClosed, Disable or Reset is called.
+func (breaker *CircuitBreaker) ForceOpen() {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	breaker.mode = ModeForcedOpen
+	breaker.setState(StateOpen)
+}
+
+// ForceClosed closes the circuit and keeps it closed, letting every request
+// through, until ForceOpen, Disable or Reset is called.
+func (breaker *CircuitBreaker) ForceClosed() {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	breaker.mode = ModeForcedClosed
+	breaker.setState(StateClosed)
+	breaker.failureCount = 0
+	breaker.successCount = 0
+}
+
+// Disable switches the breaker to metrics-only mode: requests are never
+// rejected, but outcomes and state transitions are still tracked.
+func (breaker *CircuitBreaker) Disable() {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	breaker.mode = ModeDisabled
+}
+
+// Reset clears any manual override and returns the breaker to a fresh closed
+// state. Cumulative counts are kept.
+func (breaker *CircuitBreaker) Reset() {
+	breaker.mu.Lock()
+	defer breaker.unlock()
+
+	breaker.mode = ModeNormal
+	breaker.setState(StateClosed)
+	breaker.failureCount = 0
+	breaker.successCount = 0
+	breaker.lastFailure = time.Time{}
+	breaker.reopenCount = 0
+	breaker.openDuration = 0
+	if breaker.slowCalls != nil {
+		breaker.slowCalls.reset()
+	}
+}
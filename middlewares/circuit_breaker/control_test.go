@@ -0,0 +1,226 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMode_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		mode Mode
+		want string
+	}{
+		{name: "normal", mode: ModeNormal, want: "normal"},
+		{name: "forced-open", mode: ModeForcedOpen, want: "forced-open"},
+		{name: "forced-closed", mode: ModeForcedClosed, want: "forced-closed"},
+		{name: "disabled", mode: ModeDisabled, want: "disabled"},
+		{name: "unknown", mode: Mode(99), want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.mode.String())
+		})
+	}
+}
+
+func TestCircuitBreaker_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		breaker *CircuitBreaker
+		want    Snapshot
+	}{
+		{
+			name: "closed breaker",
+			breaker: &CircuitBreaker{
+				state:           StateClosed,
+				recoverDuration: 5 * time.Second,
+				failureCount:    2,
+				counts:          Counts{Requests: 10, Successes: 8, Failures: 2},
+				now:             func() time.Time { return now },
+				onStateChange:   func(from, to State) {},
+			},
+			want: Snapshot{
+				State:  StateClosed,
+				Mode:   ModeNormal,
+				Counts: Counts{Requests: 10, Successes: 8, Failures: 2, ConsecutiveFailures: 2},
+			},
+		},
+		{
+			name: "open breaker reports time until half-open",
+			breaker: &CircuitBreaker{
+				state:           StateOpen,
+				recoverDuration: 5 * time.Second,
+				lastFailure:     now.Add(-2 * time.Second),
+				counts:          Counts{Rejections: 3},
+				now:             func() time.Time { return now },
+				onStateChange:   func(from, to State) {},
+			},
+			want: Snapshot{
+				State:         StateOpen,
+				Mode:          ModeNormal,
+				Counts:        Counts{Rejections: 3},
+				LastFailure:   now.Add(-2 * time.Second),
+				UntilHalfOpen: 3 * time.Second,
+			},
+		},
+		{
+			name: "open breaker uses backoff duration",
+			breaker: &CircuitBreaker{
+				state:           StateOpen,
+				recoverDuration: 5 * time.Second,
+				openDuration:    20 * time.Second,
+				lastFailure:     now.Add(-5 * time.Second),
+				now:             func() time.Time { return now },
+				onStateChange:   func(from, to State) {},
+			},
+			want: Snapshot{
+				State:         StateOpen,
+				Mode:          ModeNormal,
+				LastFailure:   now.Add(-5 * time.Second),
+				UntilHalfOpen: 15 * time.Second,
+			},
+		},
+		{
+			name: "forced open breaker never reaches half-open",
+			breaker: &CircuitBreaker{
+				state:           StateOpen,
+				mode:            ModeForcedOpen,
+				recoverDuration: 5 * time.Second,
+				lastFailure:     now.Add(-time.Minute),
+				now:             func() time.Time { return now },
+				onStateChange:   func(from, to State) {},
+			},
+			want: Snapshot{
+				State:       StateOpen,
+				Mode:        ModeForcedOpen,
+				LastFailure: now.Add(-time.Minute),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.breaker.Snapshot())
+		})
+	}
+}
+
+func TestCircuitBreaker_ManualControl(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		control       func(breaker *CircuitBreaker)
+		outcomes      []bool
+		wantMode      Mode
+		wantState     State
+		wantAllowErr  error
+		wantRequests  uint64
+		wantFailures  uint64
+		wantRejection uint64
+	}{
+		{
+			name:          "force open rejects requests",
+			control:       (*CircuitBreaker).ForceOpen,
+			outcomes:      nil,
+			wantMode:      ModeForcedOpen,
+			wantState:     StateOpen,
+			wantAllowErr:  ErrCircuitOpen,
+			wantRejection: 1,
+		},
+		{
+			name:         "force closed ignores failures",
+			control:      (*CircuitBreaker).ForceClosed,
+			outcomes:     []bool{true, true, true},
+			wantMode:     ModeForcedClosed,
+			wantState:    StateClosed,
+			wantRequests: 4,
+			wantFailures: 3,
+		},
+		{
+			name:         "disabled tracks state but lets requests through",
+			control:      (*CircuitBreaker).Disable,
+			outcomes:     []bool{true, true, true},
+			wantMode:     ModeDisabled,
+			wantState:    StateOpen,
+			wantRequests: 4,
+			wantFailures: 3,
+		},
+		{
+			name: "reset clears a forced override",
+			control: func(breaker *CircuitBreaker) {
+				breaker.ForceOpen()
+				breaker.Reset()
+			},
+			outcomes:     []bool{false},
+			wantMode:     ModeNormal,
+			wantState:    StateClosed,
+			wantRequests: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			breaker := NewCircuitBreaker(2, 1, time.Minute, isServerError)
+			tt.control(breaker)
+
+			for _, failed := range tt.outcomes {
+				release, err := breaker.allowRequest()
+				assert.NoError(t, err)
+				if failed {
+					breaker.recordFailure()
+				} else {
+					breaker.recordSuccess()
+				}
+				release()
+			}
+
+			_, err := breaker.allowRequest()
+			assert.ErrorIs(t, err, tt.wantAllowErr)
+
+			snapshot := breaker.Snapshot()
+			assert.Equal(t, tt.wantMode, snapshot.Mode)
+			assert.Equal(t, tt.wantState, snapshot.State)
+			assert.Equal(t, tt.wantRequests, snapshot.Counts.Requests)
+			assert.Equal(t, tt.wantFailures, snapshot.Counts.Failures)
+			assert.Equal(t, tt.wantRejection, snapshot.Counts.Rejections)
+		})
+	}
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, 1, time.Second, isServerError,
+		WithNowFunc(func() time.Time { return now }),
+		WithRecoverBackoff(2, time.Minute, 0),
+	)
+
+	breaker.recordFailure()
+	assert.Equal(t, StateOpen, breaker.State())
+
+	breaker.Reset()
+	snapshot := breaker.Snapshot()
+	assert.Equal(t, StateClosed, snapshot.State)
+	assert.Equal(t, ModeNormal, snapshot.Mode)
+	assert.Equal(t, time.Time{}, snapshot.LastFailure)
+	assert.Equal(t, uint64(1), snapshot.Counts.Failures)
+	assert.Equal(t, 0, breaker.reopenCount)
+	assert.Equal(t, time.Duration(0), breaker.openDuration)
+}
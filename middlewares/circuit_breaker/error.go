@@ -0,0 +1,73 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OpenError is returned when the breaker rejects a request. It carries enough
+// context to decide when to try again, and matches ErrCircuitOpen with
+// errors.Is.
+type OpenError struct {
+	// Name is the breaker name set by WithName.
+	Name string
+	// Key is the registry key of the breaker, empty outside a Registry.
+	Key string
+	// State is the breaker state when the request was rejected.
+	State State
+	// UntilHalfOpen is the time left before the breaker lets probes through.
+	// It is zero if the breaker is half-open or forced open.
+	UntilHalfOpen time.Duration
+}
+
+func (e *OpenError) Error() string {
+	var builder strings.Builder
+	builder.WriteString("circuit breaker")
+	if e.Name != "" {
+		fmt.Fprintf(&builder, " %q", e.Name)
+	}
+	if e.Key != "" {
+		fmt.Fprintf(&builder, " for %q", e.Key)
+	}
+	fmt.Fprintf(&builder, " is %s", e.State)
+	if e.UntilHalfOpen > 0 {
+		fmt.Fprintf(&builder, ", retry after %s", e.UntilHalfOpen)
+	}
+
+	return builder.String()
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// RetryAfter returns how long to wait before the request may be let through.
+func (e *OpenError) RetryAfter() time.Duration {
+	return e.UntilHalfOpen
+}
+
+// WithName sets the breaker name reported in OpenError.
+func WithName(name string) Option {
+	return func(breaker *CircuitBreaker) {
+		breaker.name = name
+	}
+}
+
+// withKey sets the registry key reported in OpenError.
+func withKey(key string) Option {
+	return func(breaker *CircuitBreaker) {
+		breaker.key = key
+	}
+}
+
+// openError builds the rejection error. Must be called with mu held.
+func (breaker *CircuitBreaker) openError() *OpenError {
+	return &OpenError{
+		Name:          breaker.name,
+		Key:           breaker.key,
+		State:         breaker.state,
+		UntilHalfOpen: breaker.untilHalfOpen(),
+	}
+}
@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		err            *OpenError
+		wantMessage    string
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:        "bare error",
+			err:         &OpenError{State: StateOpen},
+			wantMessage: "circuit breaker is open",
+		},
+		{
+			name:           "with name, key and remaining time",
+			err:            &OpenError{Name: "payments", Key: "api.example.com", State: StateOpen, UntilHalfOpen: 3 * time.Second},
+			wantMessage:    `circuit breaker "payments" for "api.example.com" is open, retry after 3s`,
+			wantRetryAfter: 3 * time.Second,
+		},
+		{
+			name:        "half-open with probes in flight",
+			err:         &OpenError{Key: "api.example.com", State: StateHalfOpen},
+			wantMessage: `circuit breaker for "api.example.com" is half-open`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantMessage, tt.err.Error())
+			assert.Equal(t, tt.wantRetryAfter, tt.err.RetryAfter())
+			assert.ErrorIs(t, tt.err, ErrCircuitOpen)
+			assert.ErrorIs(t, fmt.Errorf("wrapped: %w", tt.err), ErrCircuitOpen)
+			assert.NotErrorIs(t, tt.err, errors.New("circuit breaker is open"))
+		})
+	}
+}
+
+func TestCircuitBreaker_openError(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		breaker *CircuitBreaker
+		want    *OpenError
+	}{
+		{
+			name: "open breaker",
+			breaker: &CircuitBreaker{
+				name:            "payments",
+				key:             "api.example.com",
+				state:           StateOpen,
+				recoverDuration: 5 * time.Second,
+				lastFailure:     now.Add(-time.Second),
+				now:             func() time.Time { return now },
+			},
+			want: &OpenError{Name: "payments", Key: "api.example.com", State: StateOpen, UntilHalfOpen: 4 * time.Second},
+		},
+		{
+			name: "half-open breaker",
+			breaker: &CircuitBreaker{
+				name:  "payments",
+				state: StateHalfOpen,
+				now:   func() time.Time { return now },
+			},
+			want: &OpenError{Name: "payments", State: StateHalfOpen},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.breaker.openError())
+		})
+	}
+}
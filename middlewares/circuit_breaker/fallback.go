@@ -0,0 +1,101 @@
+package circuitbreaker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/htchan/goclient"
+)
+
+// FallbackFunc produces the result for a request rejected by the breaker.
+// err is the rejection error; returning it unchanged keeps the default
+// behaviour.
+type FallbackFunc func(req *http.Request, err error) (*http.Response, error)
+
+// WithFallback sets a function that is called instead of returning
+// ErrCircuitOpen when the breaker rejects a request, so callers can degrade
+// to a cached or synthetic response.
+func WithFallback(f FallbackFunc) Option {
+	return func(breaker *CircuitBreaker) {
+		breaker.fallback = f
+	}
+}
+
+type cachedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+// ResponseCache remembers the last successful response of every GET request
+// so that it can be served as a stale fallback while the circuit is open.
+type ResponseCache struct {
+	mu        sync.Mutex
+	responses map[string]cachedResponse
+	isFailure goclient.ResultValidator
+}
+
+// NewResponseCache creates an empty response cache. isFailure determines
+// which responses are not worth caching.
+func NewResponseCache(isFailure goclient.ResultValidator) *ResponseCache {
+	return &ResponseCache{
+		responses: make(map[string]cachedResponse),
+		isFailure: isFailure,
+	}
+}
+
+// Middleware records successful GET responses. It buffers the response body,
+// so it should be placed after the circuit breaker middleware.
+func (cache *ResponseCache) Middleware() goclient.Middleware {
+	return func(f goclient.Requester) goclient.Requester {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := f(req)
+			if req.Method != http.MethodGet || resp == nil || cache.isFailure(req, resp, err) {
+				return resp, err
+			}
+
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if readErr != nil {
+				return nil, fmt.Errorf("circuit breaker: failed to read response body: %w", readErr)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			cached := *resp
+			cached.Header = resp.Header.Clone()
+			cached.Body = nil
+			cached.Request = nil
+
+			cache.mu.Lock()
+			cache.responses[req.URL.String()] = cachedResponse{resp: &cached, body: body}
+			cache.mu.Unlock()
+
+			return resp, err
+		}
+	}
+}
+
+// Fallback returns a copy of the last successful response for the request
+// URL, or err if nothing was cached. It can be passed to WithFallback.
+func (cache *ResponseCache) Fallback(req *http.Request, err error) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	cached, ok := cache.responses[req.URL.String()]
+	cache.mu.Unlock()
+
+	if !ok {
+		return nil, err
+	}
+
+	resp := *cached.resp
+	resp.Header = cached.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+	resp.Request = req
+
+	return &resp, nil
+}
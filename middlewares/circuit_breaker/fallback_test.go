@@ -0,0 +1,119 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFallback(t *testing.T) {
+	t.Parallel()
+
+	fallbackResp := &http.Response{StatusCode: http.StatusNoContent}
+	breaker := NewCircuitBreaker(1, 1, time.Second, isServerError, WithFallback(
+		func(_ *http.Request, _ error) (*http.Response, error) { return fallbackResp, nil },
+	))
+
+	resp, err := breaker.fallback(nil, ErrCircuitOpen)
+	assert.NoError(t, err)
+	assert.Same(t, fallbackResp, resp)
+}
+
+func TestResponseCache(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("read error")
+
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		body         io.Reader
+		wantErr      error
+		wantBody     string
+		wantFallback bool
+	}{
+		{
+			name:         "caches successful GET response",
+			method:       http.MethodGet,
+			status:       http.StatusOK,
+			body:         strings.NewReader("cached body"),
+			wantBody:     "cached body",
+			wantFallback: true,
+		},
+		{
+			name:         "does not cache failed response",
+			method:       http.MethodGet,
+			status:       http.StatusInternalServerError,
+			body:         strings.NewReader("error body"),
+			wantBody:     "error body",
+			wantFallback: false,
+		},
+		{
+			name:         "does not cache non-GET response",
+			method:       http.MethodPost,
+			status:       http.StatusOK,
+			body:         strings.NewReader("created"),
+			wantBody:     "created",
+			wantFallback: false,
+		},
+		{
+			name:         "returns error when body cannot be read",
+			method:       http.MethodGet,
+			status:       http.StatusOK,
+			body:         io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(readErr)),
+			wantErr:      readErr,
+			wantFallback: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := NewResponseCache(isServerError)
+			wrapped := cache.Middleware()(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tt.status,
+					Header:     http.Header{"Content-Type": {"text/plain"}},
+					Body:       io.NopCloser(tt.body),
+					Request:    req,
+				}, nil
+			})
+
+			req, _ := http.NewRequest(tt.method, "http://example.com/resource", nil)
+			resp, err := wrapped(req)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+
+			fallbackReq, _ := http.NewRequest(tt.method, "http://example.com/resource", nil)
+			fallbackResp, fallbackErr := cache.Fallback(fallbackReq, ErrCircuitOpen)
+			if !tt.wantFallback {
+				assert.Nil(t, fallbackResp)
+				assert.ErrorIs(t, fallbackErr, ErrCircuitOpen)
+				return
+			}
+
+			assert.NoError(t, fallbackErr)
+			assert.Equal(t, tt.status, fallbackResp.StatusCode)
+			assert.Equal(t, "text/plain", fallbackResp.Header.Get("Content-Type"))
+			assert.Same(t, fallbackReq, fallbackResp.Request)
+
+			// every fallback response gets its own body
+			for range 2 {
+				resp, _ := cache.Fallback(fallbackReq, ErrCircuitOpen)
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
@@ -11,19 +11,46 @@ import (
 func NewCircuitBreakerMiddleware(breaker *CircuitBreaker) goclient.Middleware {
 	return func(f goclient.Requester) goclient.Requester {
 		return func(req *http.Request) (*http.Response, error) {
-			if breaker.State() == StateOpen {
-				return nil, ErrCircuitOpen
-			}
-
-			resp, err := f(req)
+			return breaker.do(f, req)
+		}
+	}
+}
 
-			if breaker.isFailure(req, resp, err) {
-				breaker.recordFailure()
-			} else {
-				breaker.recordSuccess()
-			}
+// NewRegistryMiddleware creates a middleware that routes every request
+// through the circuit breaker registered for its key.
+func NewRegistryMiddleware(registry *Registry) goclient.Middleware {
+	return func(f goclient.Requester) goclient.Requester {
+		return func(req *http.Request) (*http.Response, error) {
+			return registry.Breaker(registry.keyFunc(req)).do(f, req)
+		}
+	}
+}
 
-			return resp, err
+// do sends req through f if the breaker allows it and records the outcome.
+func (breaker *CircuitBreaker) do(f goclient.Requester, req *http.Request) (*http.Response, error) {
+	release, err := breaker.allowRequest()
+	if err != nil {
+		if breaker.fallback != nil {
+			return breaker.fallback(req, err)
 		}
+		return nil, err
+	}
+	defer release()
+
+	start := breaker.now()
+	resp, err := f(req)
+	duration := breaker.now().Sub(start)
+
+	switch breaker.classifyResult(req, resp, err) {
+	case OutcomeIgnored:
+		breaker.recordIgnored()
+	case OutcomeFailure:
+		breaker.recordCallDuration(duration)
+		breaker.recordFailure()
+	default:
+		breaker.recordCallDuration(duration)
+		breaker.recordSuccess()
 	}
+
+	return resp, err
 }
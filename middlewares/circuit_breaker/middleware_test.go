@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -11,11 +12,25 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// steppingClock returns a time source that advances by step on every call.
+func steppingClock(start time.Time, step time.Duration) func() time.Time {
+	var mu sync.Mutex
+	current := start
+	return func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		current = current.Add(step)
+		return current
+	}
+}
+
 func TestNewCircuitBreakerMiddleware(t *testing.T) {
 	t.Parallel()
 
 	now := time.Now()
 	dummyResp := &http.Response{StatusCode: http.StatusOK}
+	fallbackResp := &http.Response{StatusCode: http.StatusNonAuthoritativeInfo}
 	alwaysFail := func(_ *http.Request, _ *http.Response, _ error) bool { return true }
 	neverFail := func(_ *http.Request, _ *http.Response, _ error) bool { return false }
 
@@ -88,6 +103,58 @@ func TestNewCircuitBreakerMiddleware(t *testing.T) {
 			wantState: StateClosed,
 			wantResp:  dummyResp,
 		},
+		{
+			name: "serves fallback when open",
+			breaker: &CircuitBreaker{
+				state:            StateOpen,
+				failureThreshold: 1,
+				successThreshold: 1,
+				recoverDuration:  5 * time.Second,
+				isFailure:        neverFail,
+				lastFailure:      now,
+				fallback: func(_ *http.Request, err error) (*http.Response, error) {
+					return fallbackResp, nil
+				},
+				now:           func() time.Time { return now },
+				onStateChange: func(from, to State) {},
+			},
+			requester: func(_ *http.Request) (*http.Response, error) { return dummyResp, nil },
+			wantState: StateOpen,
+			wantResp:  fallbackResp,
+		},
+		{
+			name: "opens when slow calls reach the threshold",
+			breaker: &CircuitBreaker{
+				state:                 StateClosed,
+				failureThreshold:      1,
+				successThreshold:      1,
+				recoverDuration:       5 * time.Second,
+				isFailure:             neverFail,
+				slowCallDuration:      time.Second,
+				slowCallRateThreshold: 1,
+				slowCalls:             newSlowCallWindow(1),
+				now:                   steppingClock(now, 2*time.Second),
+				onStateChange:         func(from, to State) {},
+			},
+			requester: func(_ *http.Request) (*http.Response, error) { return dummyResp, nil },
+			wantState: StateOpen,
+			wantResp:  dummyResp,
+		},
+		{
+			name: "ignores requests cancelled by the caller",
+			breaker: &CircuitBreaker{
+				state:            StateClosed,
+				failureThreshold: 1,
+				successThreshold: 1,
+				recoverDuration:  5 * time.Second,
+				isFailure:        alwaysFail,
+				now:              func() time.Time { return now },
+				onStateChange:    func(from, to State) {},
+			},
+			requester: func(_ *http.Request) (*http.Response, error) { return nil, context.Canceled },
+			wantState: StateClosed,
+			wantErr:   context.Canceled,
+		},
 	}
 
 	for _, tt := range tests {
@@ -154,3 +221,131 @@ func TestNewCircuitBreakerMiddleware_ConcurrentAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCircuitBreakerMiddleware_HalfOpenMaxCalls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		halfOpenMaxCalls int
+		goroutines       int
+		wantAllowed      int
+	}{
+		{
+			name:             "limits concurrent probes",
+			halfOpenMaxCalls: 2,
+			goroutines:       10,
+			wantAllowed:      2,
+		},
+		{
+			name:             "unlimited probes by default",
+			halfOpenMaxCalls: 0,
+			goroutines:       10,
+			wantAllowed:      10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			breaker := NewCircuitBreaker(1, 100, time.Second, isServerError, WithHalfOpenMaxCalls(tt.halfOpenMaxCalls))
+			breaker.state = StateHalfOpen
+
+			started := make(chan struct{}, tt.goroutines)
+			unblock := make(chan struct{})
+			wrapped := NewCircuitBreakerMiddleware(breaker)(func(_ *http.Request) (*http.Response, error) {
+				started <- struct{}{}
+				<-unblock
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			})
+
+			var (
+				wg       sync.WaitGroup
+				mu       sync.Mutex
+				rejected int
+			)
+			for range tt.goroutines {
+				wg.Go(func() {
+					req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+					if _, err := wrapped(req); err != nil {
+						assert.ErrorIs(t, err, ErrCircuitOpen)
+						mu.Lock()
+						rejected++
+						mu.Unlock()
+					}
+				})
+			}
+
+			for range tt.wantAllowed {
+				<-started
+			}
+			assert.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return rejected == tt.goroutines-tt.wantAllowed
+			}, time.Second, time.Millisecond)
+
+			close(unblock)
+			wg.Wait()
+
+			assert.Equal(t, StateHalfOpen, breaker.State())
+			assert.Equal(t, 0, breaker.halfOpenCalls)
+		})
+	}
+}
+
+func TestNewRegistryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		failingURL string
+		otherURL   string
+		wantStates map[string]State
+	}{
+		{
+			name:       "failing host does not open other hosts",
+			failingURL: "http://down.com/path",
+			otherURL:   "http://ok.com/path",
+			wantStates: map[string]State{
+				"down.com": StateOpen,
+				"ok.com":   StateClosed,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewRegistry(nil, 1, 1, time.Minute, isServerError)
+			wrapped := NewRegistryMiddleware(registry)(func(req *http.Request) (*http.Response, error) {
+				if req.URL.String() == tt.failingURL {
+					return &http.Response{StatusCode: http.StatusInternalServerError}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK}, nil
+			})
+
+			failingReq, _ := http.NewRequest(http.MethodGet, tt.failingURL, nil)
+			otherReq, _ := http.NewRequest(http.MethodGet, tt.otherURL, nil)
+
+			_, err := wrapped(failingReq)
+			assert.NoError(t, err)
+
+			_, err = wrapped(failingReq)
+			assert.ErrorIs(t, err, ErrCircuitOpen)
+			var openErr *OpenError
+			if assert.ErrorAs(t, err, &openErr) {
+				assert.Equal(t, HostKey(failingReq), openErr.Key)
+				assert.InDelta(t, time.Minute, openErr.UntilHalfOpen, float64(time.Second))
+			}
+
+			resp, err := wrapped(otherReq)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			assert.Equal(t, tt.wantStates, registry.States())
+		})
+	}
+}
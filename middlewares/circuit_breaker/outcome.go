@@ -0,0 +1,80 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/htchan/goclient"
+)
+
+// Outcome is how a request result is accounted for by the breaker.
+type Outcome int
+
+const (
+	// OutcomeSuccess counts towards closing the circuit.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure counts towards opening the circuit.
+	OutcomeFailure
+	// OutcomeIgnored is not recorded at all, e.g. a request abandoned by the caller.
+	OutcomeIgnored
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeFailure:
+		return "failure"
+	case OutcomeIgnored:
+		return "ignored"
+	default:
+		return "unknown"
+	}
+}
+
+// Classifier decides how a request result is accounted for.
+type Classifier func(req *http.Request, resp *http.Response, err error) Outcome
+
+// WithClassifier replaces the default classifier, which ignores requests
+// cancelled by the caller and otherwise defers to isFailure.
+func WithClassifier(classify Classifier) Option {
+	return func(breaker *CircuitBreaker) {
+		breaker.classify = classify
+	}
+}
+
+// IgnoreCancellation returns a classifier that ignores requests whose context
+// was cancelled by the caller, and classifies every other result with
+// isFailure. Deadline errors still count as failures since they usually mean
+// the upstream is too slow.
+func IgnoreCancellation(isFailure goclient.ResultValidator) Classifier {
+	return func(req *http.Request, resp *http.Response, err error) Outcome {
+		if err != nil && (errors.Is(err, context.Canceled) || errors.Is(req.Context().Err(), context.Canceled)) {
+			return OutcomeIgnored
+		}
+		if isFailure(req, resp, err) {
+			return OutcomeFailure
+		}
+
+		return OutcomeSuccess
+	}
+}
+
+// classifyResult classifies a request result with the configured classifier.
+func (breaker *CircuitBreaker) classifyResult(req *http.Request, resp *http.Response, err error) Outcome {
+	classify := breaker.classify
+	if classify == nil {
+		classify = IgnoreCancellation(breaker.isFailure)
+	}
+
+	return classify(req, resp, err)
+}
+
+// recordIgnored records a request whose outcome does not count.
+func (breaker *CircuitBreaker) recordIgnored() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.counts.Ignored++
+}
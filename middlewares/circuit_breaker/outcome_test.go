@@ -0,0 +1,123 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcome_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		outcome Outcome
+		want    string
+	}{
+		{name: "success", outcome: OutcomeSuccess, want: "success"},
+		{name: "failure", outcome: OutcomeFailure, want: "failure"},
+		{name: "ignored", outcome: OutcomeIgnored, want: "ignored"},
+		{name: "unknown", outcome: Outcome(99), want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.outcome.String())
+		})
+	}
+}
+
+func TestIgnoreCancellation(t *testing.T) {
+	t.Parallel()
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expiredCtx, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		resp *http.Response
+		err  error
+		want Outcome
+	}{
+		{
+			name: "success",
+			ctx:  context.Background(),
+			resp: &http.Response{StatusCode: http.StatusOK},
+			want: OutcomeSuccess,
+		},
+		{
+			name: "server error is a failure",
+			ctx:  context.Background(),
+			resp: &http.Response{StatusCode: http.StatusBadGateway},
+			want: OutcomeFailure,
+		},
+		{
+			name: "transport error is a failure",
+			ctx:  context.Background(),
+			err:  errors.New("connection refused"),
+			want: OutcomeFailure,
+		},
+		{
+			name: "cancelled error is ignored",
+			ctx:  context.Background(),
+			err:  fmt.Errorf("client do request failed: %w", context.Canceled),
+			want: OutcomeIgnored,
+		},
+		{
+			name: "error on a cancelled request is ignored",
+			ctx:  cancelledCtx,
+			err:  errors.New("net/http: request canceled"),
+			want: OutcomeIgnored,
+		},
+		{
+			name: "response on a cancelled request still counts",
+			ctx:  cancelledCtx,
+			resp: &http.Response{StatusCode: http.StatusOK},
+			want: OutcomeSuccess,
+		},
+		{
+			name: "deadline exceeded is a failure",
+			ctx:  expiredCtx,
+			err:  context.DeadlineExceeded,
+			want: OutcomeFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+			assert.Equal(t, tt.want, IgnoreCancellation(isServerError)(req, tt.resp, tt.err))
+		})
+	}
+}
+
+func TestWithClassifier(t *testing.T) {
+	t.Parallel()
+
+	ignoreAll := func(_ *http.Request, _ *http.Response, _ error) Outcome { return OutcomeIgnored }
+	breaker := NewCircuitBreaker(1, 1, time.Second, isServerError, WithClassifier(ignoreAll))
+
+	assert.Equal(t, OutcomeIgnored, breaker.classifyResult(nil, nil, errors.New("test error")))
+}
+
+func TestCircuitBreaker_classifyResult(t *testing.T) {
+	t.Parallel()
+
+	breaker := &CircuitBreaker{isFailure: isServerError}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	assert.Equal(t, OutcomeFailure, breaker.classifyResult(req, nil, errors.New("test error")))
+	assert.Equal(t, OutcomeIgnored, breaker.classifyResult(req, nil, context.Canceled))
+	assert.Equal(t, OutcomeSuccess, breaker.classifyResult(req, &http.Response{StatusCode: http.StatusOK}, nil))
+}
@@ -0,0 +1,100 @@
+package circuitbreaker
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/htchan/goclient"
+)
+
+// KeyFunc returns the key that selects the circuit breaker for a request.
+type KeyFunc func(req *http.Request) string
+
+// HostKey keys circuit breakers by the request host.
+func HostKey(req *http.Request) string {
+	if req.URL != nil && req.URL.Host != "" {
+		return req.URL.Host
+	}
+	return req.Host
+}
+
+// Registry lazily creates one circuit breaker per key, so that a failing
+// destination does not open the circuit for every other destination.
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+
+	keyFunc    KeyFunc
+	newBreaker func(key string) *CircuitBreaker
+}
+
+// NewRegistry creates a registry whose breakers are all built from the same
+// template, see NewCircuitBreaker for the meaning of the arguments.
+// keyFunc selects the breaker for each request and defaults to HostKey.
+func NewRegistry(
+	keyFunc KeyFunc,
+	failureThreshold int,
+	successThreshold int,
+	recoverDuration time.Duration,
+	isFailure goclient.ResultValidator,
+	opts ...Option,
+) *Registry {
+	if keyFunc == nil {
+		keyFunc = HostKey
+	}
+
+	return &Registry{
+		breakers: make(map[string]*CircuitBreaker),
+		keyFunc:  keyFunc,
+		newBreaker: func(key string) *CircuitBreaker {
+			return NewCircuitBreaker(failureThreshold, successThreshold, recoverDuration, isFailure,
+				append(slices.Clone(opts), withKey(key))...)
+		},
+	}
+}
+
+// Breaker returns the circuit breaker for key, creating it if needed. The
+// breaker is created without holding the registry lock, so that loading its
+// state from a slow StateStore does not delay the requests to other keys.
+func (registry *Registry) Breaker(key string) *CircuitBreaker {
+	registry.mu.Lock()
+	breaker, ok := registry.breakers[key]
+	registry.mu.Unlock()
+	if ok {
+		return breaker
+	}
+
+	breaker = registry.newBreaker(key)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if existing, ok := registry.breakers[key]; ok {
+		// created by a concurrent call in the meantime
+		return existing
+	}
+	registry.breakers[key] = breaker
+
+	return breaker
+}
+
+// Breakers returns a copy of the breakers created so far, keyed by their key.
+func (registry *Registry) Breakers() map[string]*CircuitBreaker {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	return maps.Clone(registry.breakers)
+}
+
+// States returns the current state of every breaker created so far.
+func (registry *Registry) States() map[string]State {
+	states := make(map[string]State)
+	for key, breaker := range registry.Breakers() {
+		states[key] = breaker.State()
+	}
+
+	return states
+}
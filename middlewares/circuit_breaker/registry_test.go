@@ -0,0 +1,170 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{
+			name: "uses url host",
+			req:  &http.Request{URL: &url.URL{Host: "example.com:8080"}, Host: "other.com"},
+			want: "example.com:8080",
+		},
+		{
+			name: "falls back to request host",
+			req:  &http.Request{URL: &url.URL{Path: "/"}, Host: "other.com"},
+			want: "other.com",
+		},
+		{
+			name: "nil url",
+			req:  &http.Request{Host: "other.com"},
+			want: "other.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, HostKey(tt.req))
+		})
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+
+	customKey := func(req *http.Request) string { return req.Method }
+
+	tests := []struct {
+		name    string
+		keyFunc KeyFunc
+		req     *http.Request
+		wantKey string
+	}{
+		{
+			name:    "defaults to host key",
+			keyFunc: nil,
+			req:     &http.Request{Method: http.MethodGet, URL: &url.URL{Host: "example.com"}},
+			wantKey: "example.com",
+		},
+		{
+			name:    "custom key func",
+			keyFunc: customKey,
+			req:     &http.Request{Method: http.MethodGet, URL: &url.URL{Host: "example.com"}},
+			wantKey: http.MethodGet,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewRegistry(tt.keyFunc, 3, 2, time.Second, isServerError)
+			assert.Equal(t, tt.wantKey, registry.keyFunc(tt.req))
+			assert.Empty(t, registry.Breakers())
+		})
+	}
+}
+
+func TestRegistry_Breaker(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil, 3, 2, 5*time.Second, isServerError, WithName("api"))
+
+	first := registry.Breaker("a.com")
+	assert.Same(t, first, registry.Breaker("a.com"))
+	assert.Equal(t, 3, first.failureThreshold)
+	assert.Equal(t, 2, first.successThreshold)
+	assert.Equal(t, 5*time.Second, first.recoverDuration)
+	assert.Equal(t, "api", first.name)
+
+	second := registry.Breaker("b.com")
+	assert.NotSame(t, first, second)
+	assert.Equal(t, "a.com", first.key)
+	assert.Equal(t, "b.com", second.key)
+
+	breakers := registry.Breakers()
+	assert.Equal(t, map[string]*CircuitBreaker{"a.com": first, "b.com": second}, breakers)
+
+	// the returned map is a copy
+	delete(breakers, "a.com")
+	assert.Len(t, registry.Breakers(), 2)
+}
+
+// blockingLoadStateStore is a memoryStateStore whose loads of key wait for
+// release.
+type blockingLoadStateStore struct {
+	*memoryStateStore
+	key     string
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (store *blockingLoadStateStore) Load(key string) (PersistedState, bool, error) {
+	if key == store.key {
+		store.loading <- struct{}{}
+		<-store.release
+	}
+	return store.memoryStateStore.Load(key)
+}
+
+func TestRegistry_Breaker_SlowStateStore(t *testing.T) {
+	t.Parallel()
+
+	store := &blockingLoadStateStore{
+		memoryStateStore: newMemoryStateStore(),
+		key:              "api/slow.com",
+		loading:          make(chan struct{}),
+		release:          make(chan struct{}),
+	}
+	registry := NewRegistry(nil, 1, 1, time.Minute, isServerError,
+		WithName("api"), WithStateStore(store, 0, nil))
+
+	slow := make(chan *CircuitBreaker, 2)
+	for range 2 {
+		go func() { slow <- registry.Breaker("slow.com") }()
+	}
+
+	others := make(chan *CircuitBreaker)
+	go func() { others <- registry.Breaker("fast.com") }()
+	select {
+	case breaker := <-others:
+		assert.Equal(t, "fast.com", breaker.key)
+	case <-time.After(time.Second):
+		t.Error("registry blocked by a slow state store")
+	}
+
+	<-store.loading
+	<-store.loading
+	close(store.release)
+	first, second := <-slow, <-slow
+	assert.Same(t, first, second)
+	assert.Same(t, first, registry.Breaker("slow.com"))
+	assert.Len(t, registry.Breakers(), 2)
+}
+
+func TestRegistry_States(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(nil, 1, 1, time.Minute, isServerError)
+	registry.Breaker("ok.com")
+	registry.Breaker("down.com").recordFailure()
+
+	assert.Equal(t, map[string]State{
+		"ok.com":   StateClosed,
+		"down.com": StateOpen,
+	}, registry.States())
+}
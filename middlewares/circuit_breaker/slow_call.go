@@ -0,0 +1,78 @@
+package circuitbreaker
+
+import "time"
+
+// slowCallWindow is a fixed-size ring of the most recent call outcomes,
+// used to compute the slow-call rate while the breaker is closed.
+type slowCallWindow struct {
+	outcomes  []bool
+	next      int
+	count     int
+	slowCount int
+}
+
+func newSlowCallWindow(size int) *slowCallWindow {
+	if size < 1 {
+		size = 1
+	}
+
+	return &slowCallWindow{outcomes: make([]bool, size)}
+}
+
+// record adds a call outcome, evicting the oldest one once the window is full.
+func (window *slowCallWindow) record(slow bool) {
+	if window.count == len(window.outcomes) {
+		if window.outcomes[window.next] {
+			window.slowCount--
+		}
+	} else {
+		window.count++
+	}
+
+	window.outcomes[window.next] = slow
+	if slow {
+		window.slowCount++
+	}
+	window.next = (window.next + 1) % len(window.outcomes)
+}
+
+// full reports whether the window holds enough calls to be evaluated.
+func (window *slowCallWindow) full() bool {
+	return window.count == len(window.outcomes)
+}
+
+// rate returns the fraction of slow calls in the window.
+func (window *slowCallWindow) rate() float64 {
+	if window.count == 0 {
+		return 0
+	}
+
+	return float64(window.slowCount) / float64(window.count)
+}
+
+func (window *slowCallWindow) reset() {
+	clear(window.outcomes)
+	window.next = 0
+	window.count = 0
+	window.slowCount = 0
+}
+
+// WithSlowCallDetection makes the breaker treat calls taking at least
+// slowCallDuration as slow. While closed, the breaker keeps the outcome of
+// the last windowSize calls and opens once the window is full and the share
+// of slow calls reaches rateThreshold (0 < rateThreshold <= 1). In half-open
+// state a single slow probe reopens the circuit.
+func WithSlowCallDetection(slowCallDuration time.Duration, rateThreshold float64, windowSize int) Option {
+	return func(breaker *CircuitBreaker) {
+		if slowCallDuration <= 0 {
+			return
+		}
+		if rateThreshold <= 0 || rateThreshold > 1 {
+			rateThreshold = 1
+		}
+
+		breaker.slowCallDuration = slowCallDuration
+		breaker.slowCallRateThreshold = rateThreshold
+		breaker.slowCalls = newSlowCallWindow(windowSize)
+	}
+}
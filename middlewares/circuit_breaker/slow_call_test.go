@@ -0,0 +1,141 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowCallWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		size          int
+		outcomes      []bool
+		wantFull      bool
+		wantRate      float64
+		wantSlowCount int
+	}{
+		{
+			name:     "empty window",
+			size:     3,
+			outcomes: nil,
+			wantFull: false,
+			wantRate: 0,
+		},
+		{
+			name:          "partially filled window",
+			size:          4,
+			outcomes:      []bool{true, false},
+			wantFull:      false,
+			wantRate:      0.5,
+			wantSlowCount: 1,
+		},
+		{
+			name:          "full window",
+			size:          4,
+			outcomes:      []bool{true, false, true, true},
+			wantFull:      true,
+			wantRate:      0.75,
+			wantSlowCount: 3,
+		},
+		{
+			name:          "oldest outcomes are evicted",
+			size:          2,
+			outcomes:      []bool{true, true, false, false},
+			wantFull:      true,
+			wantRate:      0,
+			wantSlowCount: 0,
+		},
+		{
+			name:          "size clamped to 1",
+			size:          0,
+			outcomes:      []bool{false, true},
+			wantFull:      true,
+			wantRate:      1,
+			wantSlowCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			window := newSlowCallWindow(tt.size)
+			for _, slow := range tt.outcomes {
+				window.record(slow)
+			}
+
+			assert.Equal(t, tt.wantFull, window.full())
+			assert.Equal(t, tt.wantRate, window.rate())
+			assert.Equal(t, tt.wantSlowCount, window.slowCount)
+
+			window.reset()
+			assert.False(t, window.full())
+			assert.Equal(t, 0.0, window.rate())
+		})
+	}
+}
+
+func TestWithSlowCallDetection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		slowCallDuration  time.Duration
+		rateThreshold     float64
+		windowSize        int
+		wantDuration      time.Duration
+		wantRateThreshold float64
+		wantWindowSize    int
+		wantEnabled       bool
+	}{
+		{
+			name:              "enabled",
+			slowCallDuration:  time.Second,
+			rateThreshold:     0.5,
+			windowSize:        10,
+			wantDuration:      time.Second,
+			wantRateThreshold: 0.5,
+			wantWindowSize:    10,
+			wantEnabled:       true,
+		},
+		{
+			name:              "invalid rate threshold clamped to 1",
+			slowCallDuration:  time.Second,
+			rateThreshold:     2,
+			windowSize:        10,
+			wantDuration:      time.Second,
+			wantRateThreshold: 1,
+			wantWindowSize:    10,
+			wantEnabled:       true,
+		},
+		{
+			name:             "non-positive duration disables detection",
+			slowCallDuration: 0,
+			rateThreshold:    0.5,
+			windowSize:       10,
+			wantEnabled:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			breaker := NewCircuitBreaker(1, 1, time.Second, isServerError,
+				WithSlowCallDetection(tt.slowCallDuration, tt.rateThreshold, tt.windowSize))
+
+			if !tt.wantEnabled {
+				assert.Nil(t, breaker.slowCalls)
+				return
+			}
+
+			assert.Equal(t, tt.wantDuration, breaker.slowCallDuration)
+			assert.Equal(t, tt.wantRateThreshold, breaker.slowCallRateThreshold)
+			assert.Len(t, breaker.slowCalls.outcomes, tt.wantWindowSize)
+		})
+	}
+}
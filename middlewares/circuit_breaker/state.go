@@ -8,7 +8,7 @@ const (
 	StateClosed State = iota
 	// StateOpen rejects requests immediately.
 	StateOpen
-	// StateHalfOpen allows a limited number of probe requests.
+	// StateHalfOpen allows a limited number of probe requests (see WithHalfOpenMaxCalls).
 	StateHalfOpen
 )
 
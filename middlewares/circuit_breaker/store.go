@@ -0,0 +1,283 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PersistedState is the part of a breaker's state that is saved to a
+// StateStore.
+type PersistedState struct {
+	State        State         `json:"state"`
+	LastFailure  time.Time     `json:"last_failure"`
+	OpenDuration time.Duration `json:"open_duration"`
+	ReopenCount  int           `json:"reopen_count"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// StateStore saves breaker state so that it survives restarts and can be
+// shared between processes. Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Load returns the state saved under key, and false if there is none.
+	Load(key string) (PersistedState, bool, error)
+	// Save stores the state under key.
+	Save(key string, state PersistedState) error
+}
+
+// WithStateStore restores the breaker state from store when the breaker is
+// created and saves it on every state transition. If syncInterval is
+// positive, the breaker also reloads the stored state at most once per
+// interval and adopts it when another process saved a newer transition.
+// onError, if not nil, receives store errors; the breaker keeps working
+// with its in-memory state either way.
+//
+// Only transitions in ModeNormal are saved: manual overrides (ForceOpen,
+// ForceClosed and Disable) apply to this breaker alone, so other processes
+// never adopt a forced state, nor transitions that a disabled breaker does
+// not enforce. Reset saves the closed state.
+//
+// The store and onError are called without holding the breaker lock, so a
+// slow store only delays the call that triggered the save or sync, and
+// onError may use the breaker.
+//
+// The state is stored under the breaker name and registry key, see WithName.
+func WithStateStore(store StateStore, syncInterval time.Duration, onError func(error)) Option {
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	return func(breaker *CircuitBreaker) {
+		breaker.store = store
+		breaker.storeSyncInterval = syncInterval
+		breaker.onStoreError = onError
+	}
+}
+
+// storeKey returns the key the breaker state is stored under.
+func (breaker *CircuitBreaker) storeKey() string {
+	parts := make([]string, 0, 2)
+	for _, part := range []string{breaker.name, breaker.key} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// saveState marks the current state for saving once mu is released. Only
+// transitions in ModeNormal are saved. Must be called with mu held.
+func (breaker *CircuitBreaker) saveState() {
+	if breaker.store == nil || breaker.restoring || breaker.mode != ModeNormal {
+		return
+	}
+
+	breaker.unsaved = true
+}
+
+// unlock releases mu, then saves the state if a transition happened while
+// mu was held.
+func (breaker *CircuitBreaker) unlock() {
+	unsaved := breaker.unsaved
+	breaker.mu.Unlock()
+
+	if unsaved {
+		breaker.flushState()
+	}
+}
+
+// flushState saves the latest unsaved state. Saves are serialized so that an
+// older state never overwrites a newer one. Must be called without mu held.
+func (breaker *CircuitBreaker) flushState() {
+	breaker.storeMu.Lock()
+	defer breaker.storeMu.Unlock()
+
+	breaker.mu.Lock()
+	if !breaker.unsaved {
+		// saved by a concurrent flush already
+		breaker.mu.Unlock()
+		return
+	}
+	breaker.unsaved = false
+	if breaker.mode != ModeNormal {
+		// overridden after the transition, the override is not shared
+		breaker.mu.Unlock()
+		return
+	}
+	state := PersistedState{
+		State:        breaker.state,
+		LastFailure:  breaker.lastFailure,
+		OpenDuration: breaker.openDuration,
+		ReopenCount:  breaker.reopenCount,
+		UpdatedAt:    breaker.changedAt,
+	}
+	breaker.mu.Unlock()
+
+	if err := breaker.store.Save(breaker.storeKey(), state); err != nil {
+		breaker.onStoreError(fmt.Errorf("circuit breaker: failed to save state: %w", err))
+	}
+}
+
+// loadState adopts the stored state if it is newer than the last local
+// transition. Must be called without mu held.
+func (breaker *CircuitBreaker) loadState() {
+	if breaker.store == nil {
+		return
+	}
+
+	stored, ok, err := breaker.store.Load(breaker.storeKey())
+	if err != nil {
+		breaker.onStoreError(fmt.Errorf("circuit breaker: failed to load state: %w", err))
+		return
+	}
+	if !ok {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.adoptState(stored)
+}
+
+// adoptState switches to a stored state if it is newer than the last local
+// transition. Must be called with mu held.
+func (breaker *CircuitBreaker) adoptState(stored PersistedState) {
+	if !breaker.mode.automatic() || !stored.UpdatedAt.After(breaker.changedAt) {
+		return
+	}
+
+	// a half-open breaker is restored as open so that it reaches half-open
+	// again through the usual timeout, with fresh probe accounting
+	to := stored.State
+	if to == StateHalfOpen {
+		to = StateOpen
+	}
+
+	breaker.restoring = true
+	breaker.setState(to)
+	breaker.restoring = false
+
+	breaker.lastFailure = stored.LastFailure
+	breaker.openDuration = stored.OpenDuration
+	breaker.reopenCount = stored.ReopenCount
+	breaker.changedAt = stored.UpdatedAt
+	breaker.failureCount = 0
+	breaker.successCount = 0
+}
+
+// syncState reloads the stored state once per sync interval. Must be called
+// without mu held.
+func (breaker *CircuitBreaker) syncState() {
+	if breaker.store == nil || breaker.storeSyncInterval <= 0 {
+		return
+	}
+
+	breaker.mu.Lock()
+	now := breaker.now()
+	due := now.Sub(breaker.lastSync) >= breaker.storeSyncInterval
+	if due {
+		breaker.lastSync = now
+	}
+	breaker.mu.Unlock()
+
+	if due {
+		breaker.loadState()
+	}
+}
+
+// FileStateStore is a StateStore that keeps the state of every breaker in a
+// single JSON file. Writes replace the file atomically, so several processes
+// may share it, although concurrent writers can overwrite each other's
+// latest update.
+type FileStateStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStateStore creates a store backed by the file at path. The file is
+// created on the first save.
+func NewFileStateStore(path string) *FileStateStore {
+	return &FileStateStore{path: path}
+}
+
+// Load implements StateStore.
+func (store *FileStateStore) Load(key string) (PersistedState, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	states, err := store.read()
+	if err != nil {
+		return PersistedState{}, false, err
+	}
+
+	state, ok := states[key]
+	return state, ok, nil
+}
+
+// Save implements StateStore.
+func (store *FileStateStore) Save(key string, state PersistedState) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	states, err := store.read()
+	if err != nil {
+		return err
+	}
+	states[key] = state
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("encode state file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), store.path); err != nil {
+		return fmt.Errorf("replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// read returns every state in the file. Must be called with mu held.
+func (store *FileStateStore) read() (map[string]PersistedState, error) {
+	states := make(map[string]PersistedState)
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+	if len(data) == 0 {
+		return states, nil
+	}
+
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("decode state file: %w", err)
+	}
+
+	return states, nil
+}
@@ -0,0 +1,428 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStateStore struct {
+	mu      sync.Mutex
+	states  map[string]PersistedState
+	loadErr error
+	saveErr error
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{states: make(map[string]PersistedState)}
+}
+
+func (store *memoryStateStore) Load(key string) (PersistedState, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	state, ok := store.states[key]
+	return state, ok, store.loadErr
+}
+
+func (store *memoryStateStore) Save(key string, state PersistedState) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.saveErr != nil {
+		return store.saveErr
+	}
+	store.states[key] = state
+	return nil
+}
+
+func TestCircuitBreaker_storeKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		breaker *CircuitBreaker
+		want    string
+	}{
+		{name: "default", breaker: &CircuitBreaker{}, want: "default"},
+		{name: "name only", breaker: &CircuitBreaker{name: "payments"}, want: "payments"},
+		{name: "key only", breaker: &CircuitBreaker{key: "api.example.com"}, want: "api.example.com"},
+		{name: "name and key", breaker: &CircuitBreaker{name: "payments", key: "api.example.com"}, want: "payments/api.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.breaker.storeKey())
+		})
+	}
+}
+
+func TestWithStateStore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name             string
+		stored           map[string]PersistedState
+		wantState        State
+		wantLastFailure  time.Time
+		wantOpenDuration time.Duration
+	}{
+		{
+			name:      "nothing stored starts closed",
+			stored:    nil,
+			wantState: StateClosed,
+		},
+		{
+			name: "restores open state",
+			stored: map[string]PersistedState{
+				"payments": {State: StateOpen, LastFailure: now.Add(-time.Second), OpenDuration: 10 * time.Second, UpdatedAt: now.Add(-time.Second)},
+			},
+			wantState:        StateOpen,
+			wantLastFailure:  now.Add(-time.Second),
+			wantOpenDuration: 10 * time.Second,
+		},
+		{
+			name: "expired open state becomes half-open",
+			stored: map[string]PersistedState{
+				"payments": {State: StateOpen, LastFailure: now.Add(-time.Minute), UpdatedAt: now.Add(-time.Minute)},
+			},
+			wantState:       StateHalfOpen,
+			wantLastFailure: now.Add(-time.Minute),
+		},
+		{
+			name: "ignores other breakers",
+			stored: map[string]PersistedState{
+				"orders": {State: StateOpen, LastFailure: now, UpdatedAt: now},
+			},
+			wantState: StateClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMemoryStateStore()
+			for key, state := range tt.stored {
+				store.states[key] = state
+			}
+
+			breaker := NewCircuitBreaker(1, 1, 5*time.Second, isServerError,
+				WithName("payments"),
+				WithNowFunc(func() time.Time { return now }),
+				WithStateStore(store, 0, nil),
+			)
+
+			assert.Equal(t, tt.wantState, breaker.State())
+			assert.Equal(t, tt.wantLastFailure, breaker.lastFailure)
+			assert.Equal(t, tt.wantOpenDuration, breaker.openDuration)
+		})
+	}
+}
+
+func TestCircuitBreaker_saveState(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	store := newMemoryStateStore()
+	breaker := NewCircuitBreaker(1, 1, 5*time.Second, isServerError,
+		WithName("payments"),
+		WithNowFunc(func() time.Time { return now }),
+		WithStateStore(store, 0, nil),
+	)
+
+	breaker.recordFailure()
+	assert.Equal(t, PersistedState{State: StateOpen, LastFailure: now, UpdatedAt: now}, store.states["payments"])
+
+	// a restarted breaker picks up the open circuit
+	restarted := NewCircuitBreaker(1, 1, 5*time.Second, isServerError,
+		WithName("payments"),
+		WithNowFunc(func() time.Time { return now }),
+		WithStateStore(store, 0, nil),
+	)
+	assert.Equal(t, StateOpen, restarted.State())
+}
+
+func TestCircuitBreaker_saveState_Modes(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		control   func(breaker *CircuitBreaker)
+		wantSaved bool
+		wantState PersistedState
+	}{
+		{
+			name:      "normal mode saves transitions",
+			control:   func(breaker *CircuitBreaker) { breaker.recordFailure() },
+			wantSaved: true,
+			wantState: PersistedState{State: StateOpen, LastFailure: now, UpdatedAt: now},
+		},
+		{
+			name:    "forced open is not saved",
+			control: func(breaker *CircuitBreaker) { breaker.ForceOpen() },
+		},
+		{
+			name: "forced closed is not saved",
+			control: func(breaker *CircuitBreaker) {
+				breaker.recordFailure()
+				delete(breaker.store.(*memoryStateStore).states, "payments")
+				breaker.ForceClosed()
+			},
+		},
+		{
+			name: "disabled mode does not save transitions",
+			control: func(breaker *CircuitBreaker) {
+				breaker.Disable()
+				breaker.recordFailure()
+			},
+		},
+		{
+			name: "reset after forced open saves closed state",
+			control: func(breaker *CircuitBreaker) {
+				breaker.ForceOpen()
+				breaker.Reset()
+			},
+			wantSaved: true,
+			wantState: PersistedState{State: StateClosed, UpdatedAt: now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newMemoryStateStore()
+			breaker := NewCircuitBreaker(1, 1, 5*time.Second, isServerError,
+				WithName("payments"),
+				WithNowFunc(func() time.Time { return now }),
+				WithStateStore(store, 0, nil),
+			)
+
+			tt.control(breaker)
+
+			state, ok := store.states["payments"]
+			assert.Equal(t, tt.wantSaved, ok)
+			assert.Equal(t, tt.wantState, state)
+
+			// a new breaker on the same store is not affected by overrides
+			restarted := NewCircuitBreaker(1, 1, 5*time.Second, isServerError,
+				WithName("payments"),
+				WithNowFunc(func() time.Time { return now }),
+				WithStateStore(store, 0, nil),
+			)
+			assert.Equal(t, tt.wantState.State, restarted.State())
+		})
+	}
+}
+
+func TestCircuitBreaker_syncState(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	clock := now
+	var mu sync.Mutex
+	nowFunc := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		clock = clock.Add(d)
+	}
+
+	store := newMemoryStateStore()
+	first := NewCircuitBreaker(1, 1, time.Minute, isServerError,
+		WithNowFunc(nowFunc), WithStateStore(store, 10*time.Second, nil))
+	second := NewCircuitBreaker(1, 1, time.Minute, isServerError,
+		WithNowFunc(nowFunc), WithStateStore(store, 10*time.Second, nil))
+
+	advance(time.Second)
+	first.recordFailure()
+
+	// not synced before the interval elapses
+	_, err := second.allowRequest()
+	assert.NoError(t, err)
+
+	advance(10 * time.Second)
+	_, err = second.allowRequest()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, now.Add(time.Second), second.Snapshot().LastFailure)
+}
+
+func TestCircuitBreaker_StateStoreErrors(t *testing.T) {
+	t.Parallel()
+
+	storeErr := errors.New("store unavailable")
+	store := newMemoryStateStore()
+	store.loadErr = storeErr
+	store.saveErr = storeErr
+
+	var errs []error
+	breaker := NewCircuitBreaker(1, 1, time.Minute, isServerError,
+		WithStateStore(store, 0, func(err error) { errs = append(errs, err) }))
+	breaker.recordFailure()
+
+	assert.Equal(t, StateOpen, breaker.State())
+	if assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs[0], storeErr)
+		assert.ErrorIs(t, errs[1], storeErr)
+	}
+}
+
+// blockingStateStore is a memoryStateStore whose saves wait for release.
+type blockingStateStore struct {
+	*memoryStateStore
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (store *blockingStateStore) Save(key string, state PersistedState) error {
+	store.saving <- struct{}{}
+	<-store.release
+	return store.memoryStateStore.Save(key, state)
+}
+
+func TestCircuitBreaker_StateStoreOutsideLock(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slow save does not block requests", func(t *testing.T) {
+		t.Parallel()
+
+		store := &blockingStateStore{
+			memoryStateStore: newMemoryStateStore(),
+			saving:           make(chan struct{}),
+			release:          make(chan struct{}),
+		}
+		breaker := NewCircuitBreaker(1, 1, time.Minute, isServerError,
+			WithName("payments"), WithStateStore(store, 0, nil))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			breaker.recordFailure()
+		}()
+		<-store.saving
+
+		states := make(chan State)
+		go func() {
+			_, err := breaker.allowRequest()
+			assert.ErrorIs(t, err, ErrCircuitOpen)
+			states <- breaker.State()
+		}()
+		select {
+		case state := <-states:
+			assert.Equal(t, StateOpen, state)
+		case <-time.After(time.Second):
+			t.Error("breaker blocked by a pending save")
+		}
+
+		close(store.release)
+		<-done
+		assert.Equal(t, StateOpen, store.states["payments"].State)
+	})
+
+	t.Run("error callback may use the breaker", func(t *testing.T) {
+		t.Parallel()
+
+		store := newMemoryStateStore()
+		store.saveErr = errors.New("store unavailable")
+
+		states := []State{}
+		var breaker *CircuitBreaker
+		breaker = NewCircuitBreaker(1, 1, time.Minute, isServerError,
+			WithStateStore(store, 0, func(error) { states = append(states, breaker.State()) }))
+		breaker.recordFailure()
+
+		assert.Equal(t, []State{StateOpen}, states)
+	})
+}
+
+func TestFileStateStore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name        string
+		content     *string
+		saves       map[string]PersistedState
+		loadKey     string
+		wantState   PersistedState
+		wantOK      bool
+		wantLoadErr bool
+	}{
+		{
+			name:    "missing file loads nothing",
+			content: nil,
+			loadKey: "payments",
+			wantOK:  false,
+		},
+		{
+			name:    "empty file loads nothing",
+			content: new(""),
+			loadKey: "payments",
+			wantOK:  false,
+		},
+		{
+			name:        "corrupt file returns error",
+			content:     new("{not json"),
+			loadKey:     "payments",
+			wantLoadErr: true,
+		},
+		{
+			name: "round trip",
+			saves: map[string]PersistedState{
+				"payments": {State: StateOpen, LastFailure: now, OpenDuration: time.Minute, ReopenCount: 2, UpdatedAt: now},
+				"orders":   {State: StateClosed, UpdatedAt: now},
+			},
+			loadKey:   "payments",
+			wantState: PersistedState{State: StateOpen, LastFailure: now, OpenDuration: time.Minute, ReopenCount: 2, UpdatedAt: now},
+			wantOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "breakers.json")
+			if tt.content != nil {
+				assert.NoError(t, os.WriteFile(path, []byte(*tt.content), 0o600))
+			}
+
+			store := NewFileStateStore(path)
+			for key, state := range tt.saves {
+				assert.NoError(t, store.Save(key, state))
+			}
+
+			state, ok, err := store.Load(tt.loadKey)
+			if tt.wantLoadErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantState, state)
+
+			// another store on the same file sees the same state
+			state, ok, err = NewFileStateStore(path).Load(tt.loadKey)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantState, state)
+		})
+	}
+}
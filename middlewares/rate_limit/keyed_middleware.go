@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/htchan/goclient"
+)
+
+// KeyFunc returns the rate limit key of a request.
+type KeyFunc func(req *http.Request) string
+
+// HostKey rate limits requests by their host.
+func HostKey(req *http.Request) string {
+	if req.URL == nil {
+		return ""
+	}
+	return req.URL.Host
+}
+
+// KeyedOption configures the keyed rate limit middleware.
+type KeyedOption func(*keyedQueues)
+
+// WithMaxKeys keeps at most n queues. Once the limit is reached, a new key
+// replaces a queue whose requests have all expired, or the least recently
+// used queue if every queue is still busy. A replaced key starts over with an
+// empty queue, so n should be well above the number of keys in use within
+// one interval. Zero or a negative n keeps every key, which is the default.
+func WithMaxKeys(n int) KeyedOption {
+	if n < 0 {
+		n = 0
+	}
+
+	return func(queues *keyedQueues) {
+		queues.maxKeys = n
+	}
+}
+
+// keyedQueue is a queue with the last time it was used.
+type keyedQueue struct {
+	queue    *Queue
+	lastUsed time.Time
+}
+
+// keyedQueues lazily creates one queue per rate limit key.
+type keyedQueues struct {
+	mu       sync.Mutex
+	queues   map[string]*keyedQueue
+	newQueue func() *Queue
+	// maxKeys bounds the number of queues; zero means unlimited.
+	maxKeys int
+	// now is a function that returns the current time, injectable for testing.
+	now func() time.Time
+}
+
+func newKeyedQueues(newQueue func() *Queue, opts ...KeyedOption) *keyedQueues {
+	queues := &keyedQueues{
+		queues:   make(map[string]*keyedQueue),
+		newQueue: newQueue,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(queues)
+	}
+
+	return queues
+}
+
+func (queues *keyedQueues) get(key string) *Queue {
+	queues.mu.Lock()
+	defer queues.mu.Unlock()
+
+	now := queues.now()
+	entry, ok := queues.queues[key]
+	if !ok {
+		if queues.maxKeys > 0 && len(queues.queues) >= queues.maxKeys {
+			queues.evict(now)
+		}
+		entry = &keyedQueue{queue: queues.newQueue()}
+		queues.queues[key] = entry
+	}
+	entry.lastUsed = now
+
+	return entry.queue
+}
+
+// evict drops an idle queue, or the least recently used one if none is idle.
+// Must be called with mu held.
+func (queues *keyedQueues) evict(now time.Time) {
+	var lruKey string
+	var lru *keyedQueue
+	for key, entry := range queues.queues {
+		if idle(entry.queue, now) {
+			delete(queues.queues, key)
+			return
+		}
+		if lru == nil || entry.lastUsed.Before(lru.lastUsed) {
+			lruKey, lru = key, entry
+		}
+	}
+
+	delete(queues.queues, lruKey)
+}
+
+// idle reports whether every slot in queue has expired.
+func idle(queue *Queue, now time.Time) bool {
+	// slots of requests in flight expire late, so any slot may be the last
+	for i := range queue.Count() {
+		if item := queue.Item(i); item != nil && !item.Before(now) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewKeyedRateLimitMiddleware works like NewRateLimitMiddleware with a
+// separate queue per key, created by newQueue. The key of a request is the
+// one set with goclient.WithRateLimitKey, or defaultKey(req) otherwise.
+// Queues are kept for every key seen unless bounded with WithMaxKeys.
+func NewKeyedRateLimitMiddleware(
+	newQueue func() *Queue,
+	interval time.Duration,
+	defaultKey KeyFunc,
+	opts ...KeyedOption,
+) goclient.Middleware {
+	return keyedRateLimitMiddleware(newKeyedQueues(newQueue, opts...), interval, defaultKey)
+}
+
+func keyedRateLimitMiddleware(queues *keyedQueues, interval time.Duration, defaultKey KeyFunc) goclient.Middleware {
+	return func(f goclient.Requester) goclient.Requester {
+		return func(req *http.Request) (*http.Response, error) {
+			key := goclient.RateLimitKey(req.Context())
+			if key == "" && defaultKey != nil {
+				key = defaultKey(req)
+			}
+
+			return limit(queues.get(key), interval, f, req)
+		}
+	}
+}
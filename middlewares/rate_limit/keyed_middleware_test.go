@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/htchan/goclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostKey(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com:8443/path", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com:8443", HostKey(req))
+	assert.Equal(t, "", HostKey(&http.Request{}))
+}
+
+func TestNewKeyedRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		defaultKey KeyFunc
+		callOpts   [][]goclient.CallOption
+		wantCounts map[string]int
+	}{
+		{
+			name:       "happy flow: requests share the default key",
+			defaultKey: HostKey,
+			callOpts:   [][]goclient.CallOption{nil, nil},
+			wantCounts: map[string]int{"example.com": 2},
+		},
+		{
+			name:       "happy flow: call option overrides the default key",
+			defaultKey: HostKey,
+			callOpts: [][]goclient.CallOption{
+				nil,
+				{goclient.WithRateLimitKey("api-key-1")},
+				{goclient.WithRateLimitKey("api-key-2")},
+				{goclient.WithRateLimitKey("api-key-1")},
+			},
+			wantCounts: map[string]int{"example.com": 1, "api-key-1": 2, "api-key-2": 1},
+		},
+		{
+			name:       "happy flow: no default key",
+			defaultKey: nil,
+			callOpts:   [][]goclient.CallOption{nil},
+			wantCounts: map[string]int{"": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			queues := newKeyedQueues(func() *Queue { return NewQueue(5) })
+			cli := goclient.NewClient(
+				goclient.WithRequester(func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				}),
+				goclient.WithMiddlewares(keyedRateLimitMiddleware(queues, time.Minute, tt.defaultKey)),
+			)
+
+			for _, opts := range tt.callOpts {
+				req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+				assert.NoError(t, err)
+
+				_, err = cli.DoWithOptions(req, opts...)
+				assert.NoError(t, err)
+			}
+
+			counts := map[string]int{}
+			for key, entry := range queues.queues {
+				counts[key] = entry.queue.Count()
+			}
+			assert.Equal(t, tt.wantCounts, counts)
+		})
+	}
+}
+
+func TestKeyedQueues_MaxKeys(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	expired, busy := now.Add(-time.Second), now.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		maxKeys  int
+		slots    map[string][]*time.Time
+		wantKeys []string
+	}{
+		{
+			name:     "happy flow: unlimited keys",
+			maxKeys:  0,
+			slots:    map[string][]*time.Time{"a": {&busy}, "b": {&busy}},
+			wantKeys: []string{"a", "b", "new"},
+		},
+		{
+			name:     "happy flow: idle queue replaced first",
+			maxKeys:  2,
+			slots:    map[string][]*time.Time{"a": {&busy}, "b": {&expired}},
+			wantKeys: []string{"a", "new"},
+		},
+		{
+			name:     "happy flow: empty queue is idle",
+			maxKeys:  2,
+			slots:    map[string][]*time.Time{"a": {&busy}, "b": nil},
+			wantKeys: []string{"a", "new"},
+		},
+		{
+			name:     "happy flow: queue with a request in flight is busy",
+			maxKeys:  2,
+			slots:    map[string][]*time.Time{"a": {&busy, &expired}, "b": {&expired}},
+			wantKeys: []string{"a", "new"},
+		},
+		{
+			name:     "happy flow: least recently used queue replaced when all busy",
+			maxKeys:  2,
+			slots:    map[string][]*time.Time{"a": {&busy}, "b": {&busy}},
+			wantKeys: []string{"b", "new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := now
+			queues := newKeyedQueues(func() *Queue { return NewQueue(5) }, WithMaxKeys(tt.maxKeys))
+			queues.now = func() time.Time { return clock }
+
+			// keys are used in order, "a" being the least recently used
+			for _, key := range []string{"a", "b"} {
+				clock = clock.Add(time.Millisecond)
+				queue := queues.get(key)
+				for _, slot := range tt.slots[key] {
+					assert.NoError(t, queue.Enqueue(slot))
+				}
+			}
+			clock = clock.Add(time.Millisecond)
+			queues.get("new")
+
+			keys := make([]string, 0, len(queues.queues))
+			for key := range queues.queues {
+				keys = append(keys, key)
+			}
+			assert.ElementsMatch(t, tt.wantKeys, keys)
+		})
+	}
+}
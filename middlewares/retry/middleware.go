@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -8,17 +10,44 @@ import (
 	"github.com/htchan/goclient"
 )
 
+// Option configures the retry middleware.
+type Option func(*options)
+
+type options struct {
+	maxRetryAfter time.Duration
+}
+
+// WithMaxRetryAfter lets the middleware wait up to maxWait before the next
+// attempt when the error asks to retry later, such as an open circuit
+// breaker. Without it, the middleware never waits longer than the retry
+// interval: it stops retrying and returns the error when the hint is longer.
+func WithMaxRetryAfter(maxWait time.Duration) Option {
+	return func(opts *options) {
+		opts.maxRetryAfter = maxWait
+	}
+}
+
 func NewRetryMiddleware(
 	maxRetries int,
 	shouldRetry goclient.ResultValidator,
 	sleepDuration RetryIntervalCalculator,
+	opts ...Option,
 ) goclient.Middleware {
 	if maxRetries < 1 {
 		maxRetries = 1
 	}
 
+	var config options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	return func(f goclient.Requester) goclient.Requester {
 		return func(req *http.Request) (*http.Response, error) {
+			if goclient.SkipRetry(req.Context()) {
+				return f(req)
+			}
+
 			var (
 				resp *http.Response
 				err  error
@@ -30,11 +59,17 @@ func NewRetryMiddleware(
 				if !shouldRetry || i == maxRetries-1 { // no need to sleep for last trial
 					break
 				}
+				interval, ok := retryInterval(sleepDuration(i, req, resp), config.maxRetryAfter, err)
+				if !ok {
+					break
+				}
 				if resp != nil {
 					resp.Body.Close()
 				}
 
-				time.Sleep(sleepDuration(i, req, resp))
+				if waitErr := wait(req.Context(), interval); waitErr != nil {
+					return nil, fmt.Errorf("retry: gave up waiting after %d attempts: %w", i+1, waitErr)
+				}
 
 				// reset request body for next retry attempt
 				if req.Body != nil && req.GetBody != nil {
@@ -51,6 +86,42 @@ func NewRetryMiddleware(
 	}
 }
 
+// wait sleeps for d, and returns the cause of ctx if it is done first.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// retryAfterHinter is implemented by errors that know when a retry may
+// succeed, such as the circuit breaker's OpenError.
+type retryAfterHinter interface {
+	RetryAfter() time.Duration
+}
+
+// retryInterval returns how long to wait before the next attempt, at least
+// as long as the error suggests. It returns false if the error suggests
+// waiting longer than both interval and maxRetryAfter.
+func retryInterval(interval, maxRetryAfter time.Duration, err error) (time.Duration, bool) {
+	var hinter retryAfterHinter
+	if !errors.As(err, &hinter) {
+		return interval, true
+	}
+
+	hint := hinter.RetryAfter()
+	if hint > max(interval, maxRetryAfter) {
+		return 0, false
+	}
+
+	return max(interval, hint), true
+}
+
 func RetryForError(_ *http.Request, _ *http.Response, err error) bool {
 	return err != nil
 }
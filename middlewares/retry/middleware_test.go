@@ -1,7 +1,9 @@
 package retry
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -183,6 +185,128 @@ func TestRetryForError(t *testing.T) {
 	}
 }
 
+type retryAfterError time.Duration
+
+func (e retryAfterError) Error() string             { return "retry later" }
+func (e retryAfterError) RetryAfter() time.Duration { return time.Duration(e) }
+
+func TestRetryInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		interval      time.Duration
+		maxRetryAfter time.Duration
+		err           error
+		want          time.Duration
+		wantOK        bool
+	}{
+		{
+			name:     "no error keeps interval",
+			interval: time.Second,
+			err:      nil,
+			want:     time.Second,
+			wantOK:   true,
+		},
+		{
+			name:     "plain error keeps interval",
+			interval: time.Second,
+			err:      errors.New("test error"),
+			want:     time.Second,
+			wantOK:   true,
+		},
+		{
+			name:     "longer hint stops retrying",
+			interval: time.Second,
+			err:      fmt.Errorf("wrapped: %w", retryAfterError(3*time.Second)),
+			want:     0,
+			wantOK:   false,
+		},
+		{
+			name:          "longer hint within max overrides interval",
+			interval:      time.Second,
+			maxRetryAfter: 5 * time.Second,
+			err:           fmt.Errorf("wrapped: %w", retryAfterError(3*time.Second)),
+			want:          3 * time.Second,
+			wantOK:        true,
+		},
+		{
+			name:          "hint over max stops retrying",
+			interval:      time.Second,
+			maxRetryAfter: 2 * time.Second,
+			err:           retryAfterError(3 * time.Second),
+			want:          0,
+			wantOK:        false,
+		},
+		{
+			name:     "shorter hint keeps interval",
+			interval: time.Second,
+			err:      retryAfterError(time.Millisecond),
+			want:     time.Second,
+			wantOK:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := retryInterval(test.interval, test.maxRetryAfter, test.err)
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.wantOK, ok)
+		})
+	}
+}
+
+func TestNewRetryMiddleware_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantCallCount int
+		wantMinWait   time.Duration
+	}{
+		{
+			name:          "happy flow: long hint returns the error right away",
+			opts:          nil,
+			wantCallCount: 1,
+		},
+		{
+			name:          "happy flow: hint within max is waited for",
+			opts:          []Option{WithMaxRetryAfter(time.Second)},
+			wantCallCount: 2,
+			wantMinWait:   50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			callCount := 0
+			cli := goclient.NewClient(
+				goclient.WithRequester(func(req *http.Request) (*http.Response, error) {
+					callCount++
+					return nil, retryAfterError(50 * time.Millisecond)
+				}),
+				goclient.WithMiddlewares(
+					NewRetryMiddleware(2, RetryForError, StaticRetryInterval(time.Millisecond), tt.opts...),
+				),
+			)
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			start := time.Now()
+			_, err := cli.Do(req)
+			assert.ErrorIs(t, err, retryAfterError(50*time.Millisecond))
+			assert.Equal(t, tt.wantCallCount, callCount)
+			assert.GreaterOrEqual(t, time.Since(start), tt.wantMinWait)
+		})
+	}
+}
+
 func TestNewRetryMiddleware_BodyReplay(t *testing.T) {
 	t.Parallel()
 
@@ -251,3 +375,73 @@ func TestNewRetryMiddleware_BodyReplay(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRetryMiddleware_ContextDone(t *testing.T) {
+	t.Parallel()
+
+	callCount := 0
+	cli := goclient.NewClient(
+		goclient.WithRequester(func(req *http.Request) (*http.Response, error) {
+			callCount++
+			return nil, errors.New("test error")
+		}),
+		goclient.WithMiddlewares(
+			NewRetryMiddleware(3, RetryForError, StaticRetryInterval(time.Second)),
+		),
+	)
+
+	req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, reqErr)
+
+	start := time.Now()
+	resp, err := cli.DoWithOptions(req, goclient.WithTimeout(100*time.Millisecond))
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestNewRetryMiddleware_SkipRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		opts          []goclient.CallOption
+		wantCallCount int
+	}{
+		{
+			name:          "happy flow: retries by default",
+			opts:          nil,
+			wantCallCount: 3,
+		},
+		{
+			name:          "happy flow: call skips retry",
+			opts:          []goclient.CallOption{goclient.WithSkipRetry()},
+			wantCallCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			callCount := 0
+			cli := goclient.NewClient(
+				goclient.WithRequester(func(req *http.Request) (*http.Response, error) {
+					callCount++
+					return nil, errors.New("test error")
+				}),
+				goclient.WithMiddlewares(
+					NewRetryMiddleware(3, RetryForError, StaticRetryInterval(time.Millisecond)),
+				),
+			)
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			_, err := cli.DoWithOptions(req, tt.opts...)
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantCallCount, callCount)
+		})
+	}
+}
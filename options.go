@@ -17,5 +17,6 @@ func WithMiddlewares(middlewares ...Middleware) ClientOption {
 func WithRequester(requester Requester) ClientOption {
 	return func(client *Client) {
 		client.requester = requester
+		client.parent = nil
 	}
 }
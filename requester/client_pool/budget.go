@@ -0,0 +1,51 @@
+package pool
+
+import "time"
+
+// budget is a token bucket limiting how often a client may be handed out.
+type budget struct {
+	capacity float64
+	tokens   float64
+	// refill is how long it takes to earn one token back.
+	refill  time.Duration
+	updated time.Time
+}
+
+func newBudget(limit int, interval time.Duration) *budget {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &budget{
+		capacity: float64(limit),
+		tokens:   float64(limit),
+		refill:   interval / time.Duration(limit),
+	}
+}
+
+// update adds the tokens earned since the last update.
+func (b *budget) update(now time.Time) {
+	if !b.updated.IsZero() && b.refill > 0 {
+		b.tokens = min(b.capacity, b.tokens+float64(now.Sub(b.updated))/float64(b.refill))
+	} else if b.refill <= 0 {
+		b.tokens = b.capacity
+	}
+	b.updated = now
+}
+
+// wait returns how long until a request is allowed, zero if it is allowed
+// now.
+func (b *budget) wait(now time.Time) time.Duration {
+	b.update(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - b.tokens) * float64(b.refill))
+}
+
+// take spends a token. It must only be called once wait returned zero.
+func (b *budget) take(now time.Time) {
+	b.update(now)
+	b.tokens = max(b.tokens-1, 0)
+}
@@ -0,0 +1,101 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limit    int
+		interval time.Duration
+		takes    []time.Duration
+		at       time.Duration
+		wantWait time.Duration
+	}{
+		{
+			name:     "happy flow: fresh budget allows a request",
+			limit:    1,
+			interval: time.Second,
+			takes:    nil,
+			at:       0,
+			wantWait: 0,
+		},
+		{
+			name:     "happy flow: spent budget waits for refill",
+			limit:    1,
+			interval: time.Second,
+			takes:    []time.Duration{0},
+			at:       300 * time.Millisecond,
+			wantWait: 700 * time.Millisecond,
+		},
+		{
+			name:     "happy flow: budget refilled after interval",
+			limit:    1,
+			interval: time.Second,
+			takes:    []time.Duration{0},
+			at:       time.Second,
+			wantWait: 0,
+		},
+		{
+			name:     "happy flow: burst up to limit",
+			limit:    3,
+			interval: 3 * time.Second,
+			takes:    []time.Duration{0, 0},
+			at:       0,
+			wantWait: 0,
+		},
+		{
+			name:     "happy flow: burst spent",
+			limit:    3,
+			interval: 3 * time.Second,
+			takes:    []time.Duration{0, 0, 0},
+			at:       0,
+			wantWait: time.Second,
+		},
+		{
+			name:     "happy flow: refill does not exceed limit",
+			limit:    2,
+			interval: time.Second,
+			takes:    []time.Duration{0, time.Hour, time.Hour, time.Hour},
+			at:       time.Hour,
+			wantWait: 500 * time.Millisecond,
+		},
+		{
+			name:     "happy flow: non-positive limit clamped to 1",
+			limit:    0,
+			interval: time.Second,
+			takes:    []time.Duration{0},
+			at:       0,
+			wantWait: time.Second,
+		},
+		{
+			name:     "happy flow: zero interval never limits",
+			limit:    1,
+			interval: 0,
+			takes:    []time.Duration{0, 0},
+			at:       0,
+			wantWait: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b := newBudget(tt.limit, tt.interval)
+			for _, at := range tt.takes {
+				b.take(start.Add(at))
+			}
+
+			assert.Equal(t, tt.wantWait, b.wait(start.Add(tt.at)))
+		})
+	}
+}
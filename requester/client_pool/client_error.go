@@ -0,0 +1,75 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ClientInfo identifies the pooled client a request was sent with.
+type ClientInfo struct {
+	Client *http.Client
+	// Index is the order in which the client joined the pool, -1 if the
+	// client was removed from the pool while the request was in flight or
+	// if the pool is not a ClientStatsReporter.
+	Index int
+	// Label is the label set with WithLabel, if any.
+	Label string
+}
+
+func (info ClientInfo) String() string {
+	if info.Label != "" {
+		return fmt.Sprintf("client %d (%s)", info.Index, info.Label)
+	}
+	return fmt.Sprintf("client %d", info.Index)
+}
+
+// ClientError is returned when the request failed with a pooled client.
+type ClientError struct {
+	ClientInfo
+	Err error
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("client do request failed with %s: %v", e.ClientInfo, e.Err)
+}
+
+func (e *ClientError) Unwrap() error {
+	return e.Err
+}
+
+type clientInfoKey struct{}
+
+// UsedClient returns the pooled client a response was received with.
+func UsedClient(resp *http.Response) (ClientInfo, bool) {
+	if resp == nil || resp.Request == nil {
+		return ClientInfo{}, false
+	}
+
+	info, ok := resp.Request.Context().Value(clientInfoKey{}).(ClientInfo)
+	return info, ok
+}
+
+// clientInfo looks up the identity of a client in pool.
+func clientInfo(pool ClientPool, cli *http.Client) ClientInfo {
+	reporter, ok := pool.(ClientStatsReporter)
+	if !ok {
+		return ClientInfo{Client: cli, Index: -1}
+	}
+
+	stats, ok := reporter.ClientStats(cli)
+	if !ok {
+		return ClientInfo{Client: cli, Index: -1}
+	}
+
+	return ClientInfo{Client: cli, Index: stats.Index, Label: stats.Label}
+}
+
+// withClientInfo attaches the client identity to the request of resp.
+func withClientInfo(resp *http.Response, info ClientInfo) {
+	if resp.Request == nil {
+		return
+	}
+
+	resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), clientInfoKey{}, info))
+}
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientError(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		name      string
+		err       *ClientError
+		wantError string
+	}{
+		{
+			name:      "happy flow: labelled client",
+			err:       &ClientError{ClientInfo: ClientInfo{Index: 2, Label: "http://proxy-a:8080"}, Err: errTest},
+			wantError: "client do request failed with client 2 (http://proxy-a:8080): test error",
+		},
+		{
+			name:      "happy flow: unlabelled client",
+			err:       &ClientError{ClientInfo: ClientInfo{Index: 0}, Err: errTest},
+			wantError: "client do request failed with client 0: test error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.EqualError(t, tt.err, tt.wantError)
+			assert.ErrorIs(t, tt.err, errTest)
+		})
+	}
+}
+
+func TestUsedClient(t *testing.T) {
+	t.Parallel()
+
+	info := ClientInfo{Client: &http.Client{}, Index: 1, Label: "proxy-a"}
+
+	tests := []struct {
+		name     string
+		resp     func() *http.Response
+		wantInfo ClientInfo
+		wantOK   bool
+	}{
+		{
+			name:   "happy flow: nil response",
+			resp:   func() *http.Response { return nil },
+			wantOK: false,
+		},
+		{
+			name:   "happy flow: response without request",
+			resp:   func() *http.Response { return &http.Response{} },
+			wantOK: false,
+		},
+		{
+			name: "happy flow: response not from the pool",
+			resp: func() *http.Response {
+				return &http.Response{Request: &http.Request{}}
+			},
+			wantOK: false,
+		},
+		{
+			name: "happy flow: response from the pool",
+			resp: func() *http.Response {
+				resp := &http.Response{Request: (&http.Request{}).WithContext(context.Background())}
+				withClientInfo(resp, info)
+				return resp
+			},
+			wantInfo: info,
+			wantOK:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := UsedClient(tt.resp())
+			assert.Equal(t, tt.wantInfo, got)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
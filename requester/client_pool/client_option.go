@@ -0,0 +1,104 @@
+package pool
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClientOption configures how the pool treats a single client.
+type ClientOption func(*clientConfig)
+
+// clientConfig holds the per-client settings set with ConfigureClient. It is
+// kept until the client is removed from the pool.
+type clientConfig struct {
+	label string
+	tags  []string
+	// budget, if set, limits how often the client is handed out.
+	budget *budget
+	// maxConcurrency is how many requests the client may serve at once, one
+	// if not set.
+	maxConcurrency int
+}
+
+// WithLabel names a client, e.g. after the proxy it goes through, so that it
+// can be told apart in statistics.
+func WithLabel(label string) ClientOption {
+	return func(config *clientConfig) {
+		config.label = label
+	}
+}
+
+// WithRateLimit allows at most limit requests with the client per interval,
+// e.g. WithRateLimit(1, time.Second) for a proxy or API key allowed one
+// request per second. Up to limit requests may be made in a burst. While the
+// client is over its budget the pool hands out other clients, or waits until
+// a client is allowed a request again.
+func WithRateLimit(limit int, interval time.Duration) ClientOption {
+	return func(config *clientConfig) {
+		config.budget = newBudget(limit, interval)
+	}
+}
+
+// WithMaxConcurrency lets the client serve up to limit requests at once, e.g.
+// for a proxy that accepts several connections. The client stays available
+// while it serves fewer requests than limit; once it reaches the limit, it is
+// added back by the recorders as usual. A client is handed out to one request
+// at a time by default. The limit applies from the next time the client is
+// handed out or added back.
+func WithMaxConcurrency(limit int) ClientOption {
+	return func(config *clientConfig) {
+		config.maxConcurrency = limit
+	}
+}
+
+// ConfigureClient applies per-client settings such as a label. It may be
+// called before or after the client is added, and the settings are kept until
+// the client is removed.
+func (pool *Pool) ConfigureClient(cli *http.Client, opts ...ClientOption) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.configs == nil {
+		pool.configs = make(map[*http.Client]*clientConfig)
+	}
+
+	config, ok := pool.configs[cli]
+	if !ok {
+		config = &clientConfig{}
+		pool.configs[cli] = config
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	pool.cond.Broadcast()
+}
+
+// config returns the settings of a client, which are empty if it was never
+// configured. Must be called with mutex held.
+func (pool *Pool) config(cli *http.Client) clientConfig {
+	if config, ok := pool.configs[cli]; ok {
+		return *config
+	}
+
+	return clientConfig{}
+}
+
+// untilAllowed returns how long until a request is allowed with the client,
+// zero if it is allowed now. Must be called with mutex held.
+func (pool *Pool) untilAllowed(cli *http.Client, now time.Time) time.Duration {
+	if config, ok := pool.configs[cli]; ok && config.budget != nil {
+		return config.budget.wait(now)
+	}
+
+	return 0
+}
+
+// concurrency returns how many requests the client may serve at once. Must
+// be called with mutex held.
+func (pool *Pool) concurrency(cli *http.Client) int {
+	if config, ok := pool.configs[cli]; ok && config.maxConcurrency > 1 {
+		return config.maxConcurrency
+	}
+
+	return 1
+}
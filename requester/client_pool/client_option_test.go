@@ -0,0 +1,235 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_ConfigureClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		configure func(pool *Pool, cli *http.Client)
+		wantLabel string
+	}{
+		{
+			name:      "happy flow: not configured",
+			configure: func(pool *Pool, cli *http.Client) {},
+			wantLabel: "",
+		},
+		{
+			name: "happy flow: label set before the client is added",
+			configure: func(pool *Pool, cli *http.Client) {
+				pool.ConfigureClient(cli, WithLabel("proxy-a"))
+				pool.AddClients(cli)
+			},
+			wantLabel: "proxy-a",
+		},
+		{
+			name: "happy flow: label overridden",
+			configure: func(pool *Pool, cli *http.Client) {
+				pool.AddClients(cli)
+				pool.ConfigureClient(cli, WithLabel("proxy-a"))
+				pool.ConfigureClient(cli, WithLabel("proxy-b"))
+			},
+			wantLabel: "proxy-b",
+		},
+		{
+			name: "happy flow: removing the client forgets its label",
+			configure: func(pool *Pool, cli *http.Client) {
+				pool.ConfigureClient(cli, WithLabel("proxy-a"))
+				pool.AddClients(cli)
+				pool.RemoveClients(cli)
+			},
+			wantLabel: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			cli := &http.Client{}
+			tt.configure(pool, cli)
+
+			pool.AddClients(cli)
+			stats, ok := pool.ClientStats(cli)
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantLabel, stats.Label)
+
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+			assert.Equal(t, tt.wantLabel, pool.candidates(pool.matcher(nil, nil), time.Now())[0].Label)
+		})
+	}
+}
+
+func TestPool_AcquireClient_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		wantSecond  func(limited, other *http.Client) *http.Client
+		withOther   bool
+		wantMinWait time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "happy flow: skips the client over its budget",
+			timeout:     time.Second,
+			withOther:   true,
+			wantSecond:  func(limited, other *http.Client) *http.Client { return other },
+			wantMinWait: 0,
+		},
+		{
+			name:        "happy flow: waits until the budget allows a request",
+			timeout:     time.Second,
+			withOther:   false,
+			wantSecond:  func(limited, other *http.Client) *http.Client { return limited },
+			wantMinWait: 80 * time.Millisecond,
+		},
+		{
+			name:        "error flow: times out while rate limited",
+			timeout:     20 * time.Millisecond,
+			withOther:   false,
+			wantSecond:  func(limited, other *http.Client) *http.Client { return nil },
+			wantMinWait: 20 * time.Millisecond,
+			wantErr:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			limited, other := &http.Client{}, &http.Client{}
+			pool := NewClientPool(WithAcquireTimeout(tt.timeout))
+			pool.ConfigureClient(limited, WithRateLimit(1, 100*time.Millisecond))
+			// two slots so that the limited client is still available after
+			// being handed out once
+			pool.AddClients(limited, limited)
+			if tt.withOther {
+				pool.AddClients(other)
+			}
+
+			first, err := pool.AcquireClient(context.Background(), &http.Request{})
+			assert.NoError(t, err)
+			assert.Same(t, limited, first)
+
+			start := time.Now()
+			second, err := pool.AcquireClient(context.Background(), &http.Request{})
+			assert.Equal(t, tt.wantSecond(limited, other), second)
+			assert.GreaterOrEqual(t, time.Since(start), tt.wantMinWait)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr) && errors.Is(err, ErrNoClientAvailable))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPool_AcquireClient_MaxConcurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		wantClients    int
+		// wantSlots is how many times the client is available once added
+		// back twice.
+		wantSlots int
+	}{
+		{
+			name:           "happy flow: client serves up to its limit",
+			maxConcurrency: 3,
+			wantClients:    3,
+			wantSlots:      1,
+		},
+		{
+			name:           "happy flow: one request at a time by default",
+			maxConcurrency: 0,
+			wantClients:    1,
+			wantSlots:      2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cli := &http.Client{}
+			pool := NewClientPool(WithAcquireTimeout(20 * time.Millisecond))
+			pool.ConfigureClient(cli, WithMaxConcurrency(tt.maxConcurrency))
+			pool.AddClients(cli)
+
+			for range tt.wantClients {
+				got, err := pool.AcquireClient(context.Background(), &http.Request{})
+				assert.NoError(t, err)
+				assert.Same(t, cli, got)
+			}
+
+			_, err := pool.AcquireClient(context.Background(), &http.Request{})
+			assert.ErrorIs(t, err, ErrNoClientAvailable)
+			assert.Equal(t, tt.wantClients, pool.Stats().InUse)
+
+			pool.AddClients(cli)
+			pool.AddClients(cli)
+			assert.Len(t, pool.clients, tt.wantSlots)
+		})
+	}
+}
+
+func TestPool_AcquireClient_LeastInFlight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		strategy   Strategy
+		wantClient int
+	}{
+		{
+			name:       "happy flow: least in flight picks the idle client",
+			strategy:   NewStrategyLeastInFlight(),
+			wantClient: 1,
+		},
+		{
+			name:       "happy flow: FIFO picks the first available client",
+			strategy:   nil,
+			wantClient: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			clients := []*http.Client{{}, {}}
+			pool := NewClientPool(WithStrategy(tt.strategy))
+			for _, cli := range clients {
+				pool.ConfigureClient(cli, WithMaxConcurrency(2))
+			}
+			pool.AddClients(clients...)
+
+			// both clients busy, then the second one finishes its request
+			for range clients {
+				_, err := pool.AcquireClient(context.Background(), &http.Request{})
+				assert.NoError(t, err)
+			}
+			pool.AddClients(clients[1])
+			pool.ReportResult(RequestResult{Client: clients[1]})
+
+			got, err := pool.AcquireClient(context.Background(), &http.Request{})
+			assert.NoError(t, err)
+			assert.Same(t, clients[tt.wantClient], got)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/htchan/goclient"
+)
+
+// ProbeFunc builds the request used to check whether a quarantined client is
+// healthy again.
+type ProbeFunc func(ctx context.Context) (*http.Request, error)
+
+// HealthChecker periodically probes the quarantined clients of a pool and
+// revives the ones that are healthy again.
+type HealthChecker struct {
+	pool      Quarantiner
+	interval  time.Duration
+	newProbe  ProbeFunc
+	isHealthy goclient.ResultValidator
+}
+
+// NewHealthChecker creates a health checker for pool. Every interval, each
+// quarantined client sends the request built by newProbe, and is revived if
+// isHealthy returns true for the result.
+func NewHealthChecker(
+	pool Quarantiner,
+	interval time.Duration,
+	newProbe ProbeFunc,
+	isHealthy goclient.ResultValidator,
+) *HealthChecker {
+	return &HealthChecker{
+		pool:      pool,
+		interval:  interval,
+		newProbe:  newProbe,
+		isHealthy: isHealthy,
+	}
+}
+
+// Run checks the quarantined clients every interval until ctx is done.
+func (checker *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(checker.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checker.Check(ctx)
+		}
+	}
+}
+
+// Check probes every quarantined client once, revives the healthy ones and
+// returns them.
+func (checker *HealthChecker) Check(ctx context.Context) []*http.Client {
+	clients := checker.pool.QuarantinedClients()
+	healthy := make([]bool, len(clients))
+
+	var wg sync.WaitGroup
+	for i, cli := range clients {
+		wg.Go(func() {
+			healthy[i] = checker.probe(ctx, cli)
+		})
+	}
+	wg.Wait()
+
+	var revived []*http.Client
+	for i, cli := range clients {
+		if healthy[i] {
+			revived = append(revived, cli)
+		}
+	}
+	checker.pool.ReviveClients(revived...)
+
+	return revived
+}
+
+// probe sends a probe request with cli and reports whether it is healthy.
+func (checker *HealthChecker) probe(ctx context.Context, cli *http.Client) bool {
+	req, err := checker.newProbe(ctx)
+	if err != nil {
+		return false
+	}
+
+	resp, err := cli.Do(req)
+	if resp != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	return checker.isHealthy(req, resp, err)
+}
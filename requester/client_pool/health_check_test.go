@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func isStatusOK(_ *http.Request, resp *http.Response, err error) bool {
+	return err == nil && resp.StatusCode == http.StatusOK
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	t.Parallel()
+
+	// each client always answers with its own status, whatever the probe URL is
+	clientFor := func(status int) *http.Client {
+		return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(status)
+			return rec.Result(), nil
+		})}
+	}
+	healthy, unhealthy := clientFor(http.StatusOK), clientFor(http.StatusBadGateway)
+
+	probeErr := errors.New("probe error")
+
+	tests := []struct {
+		name            string
+		newProbe        ProbeFunc
+		wantRevived     []*http.Client
+		wantQuarantined []*http.Client
+	}{
+		{
+			name: "happy flow: revives healthy clients only",
+			newProbe: func(ctx context.Context) (*http.Request, error) {
+				return http.NewRequestWithContext(ctx, http.MethodGet, "http://probe.invalid/health", nil)
+			},
+			wantRevived:     []*http.Client{healthy},
+			wantQuarantined: []*http.Client{unhealthy},
+		},
+		{
+			name: "error flow: probe cannot be built",
+			newProbe: func(ctx context.Context) (*http.Request, error) {
+				return nil, probeErr
+			},
+			wantRevived:     nil,
+			wantQuarantined: []*http.Client{unhealthy, healthy},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.AddClients(unhealthy, healthy)
+			pool.QuarantineClients(unhealthy, healthy)
+
+			checker := NewHealthChecker(pool, time.Second, tt.newProbe, isStatusOK)
+			assert.Equal(t, tt.wantRevived, checker.Check(context.Background()))
+			assert.Equal(t, tt.wantQuarantined, pool.QuarantinedClients())
+		})
+	}
+}
+
+func TestHealthChecker_Run(t *testing.T) {
+	t.Parallel()
+
+	var probes atomic.Int32
+	cli := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		probes.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+
+	pool := NewClientPool()
+	pool.AddClients(cli)
+	pool.QuarantineClients(cli)
+
+	checker := NewHealthChecker(pool, 10*time.Millisecond, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, "http://probe.invalid/health", nil)
+	}, isStatusOK)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return len(pool.QuarantinedClients()) == 0 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(1), probes.Load())
+	got, err := pool.AcquireClient(context.Background(), &http.Request{})
+	assert.NoError(t, err)
+	assert.Same(t, cli, got)
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// AddClientsAfter adds the given clients once delay has passed, unless
+// they are removed or the pool is closed in the meantime. It never gives a
+// client a second slot: clients already available are skipped, and a client
+// with a pending addition keeps the later of the two.
+func (pool *Pool) AddClientsAfter(delay time.Duration, clients ...*http.Client) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.closed {
+		return
+	}
+	if pool.pending == nil {
+		pool.pending = make(map[*http.Client]*pendingAdd)
+	}
+
+	at := time.Now().Add(delay)
+	for _, cli := range clients {
+		if pool.retired(cli) || slices.Contains(pool.clients, cli) {
+			continue
+		}
+		if current, ok := pool.pending[cli]; ok {
+			if !at.After(current.at) {
+				continue
+			}
+			current.timer.Stop()
+		}
+
+		add := &pendingAdd{at: at}
+		add.timer = time.AfterFunc(delay, func() {
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+
+			// the timer may have been stopped or replaced after it started
+			// firing
+			if pool.pending[cli] != add {
+				return
+			}
+			delete(pool.pending, cli)
+			if !slices.Contains(pool.clients, cli) {
+				pool.addClients([]*http.Client{cli})
+			}
+		})
+		pool.pending[cli] = add
+	}
+}
+
+// pendingAdd is an AddClientsAfter call that has not fired yet.
+type pendingAdd struct {
+	timer *time.Timer
+	at    time.Time
+}
+
+// stopPending cancels the pending additions of the clients matching
+// shouldStop. Must be called with mutex held.
+func (pool *Pool) stopPending(shouldStop func(cli *http.Client) bool) {
+	for cli, add := range pool.pending {
+		if shouldStop(cli) {
+			add.timer.Stop()
+			delete(pool.pending, cli)
+		}
+	}
+}
+
+// Close stops pending AddClientsAfter calls, fails waiting and future
+// acquisitions with ErrPoolClosed, then waits until in-flight requests have
+// been reported or ctx is done.
+func (pool *Pool) Close(ctx context.Context) error {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.closed = true
+	pool.stopPending(func(*http.Client) bool { return true })
+	pool.clients = nil
+	pool.cond.Broadcast()
+
+	stop := context.AfterFunc(ctx, func() {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+
+		pool.cond.Broadcast()
+	})
+	defer stop()
+
+	for pool.inFlight() > 0 {
+		if ctx.Err() != nil {
+			return fmt.Errorf("wait for in-flight requests: %w", context.Cause(ctx))
+		}
+
+		pool.cond.Wait()
+	}
+
+	return nil
+}
+
+// inFlight returns the number of requests made with clients of the pool
+// that have not been reported yet. Must be called with mutex held.
+func (pool *Pool) inFlight() int {
+	total := 0
+	for _, state := range pool.members {
+		total += state.inFlight
+	}
+	for _, state := range pool.quarantined {
+		total += state.inFlight
+	}
+	return total
+}
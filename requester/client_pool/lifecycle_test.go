@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_AddClientsAfter(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2 := &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name        string
+		afterAdd    func(pool *Pool)
+		wantClients []*http.Client
+		wantPending int
+	}{
+		{
+			name:        "happy flow: clients added after delay",
+			afterAdd:    func(pool *Pool) {},
+			wantClients: []*http.Client{cli1, cli2},
+			wantPending: 0,
+		},
+		{
+			name: "happy flow: removed client is not added back",
+			afterAdd: func(pool *Pool) {
+				pool.RemoveClients(cli1)
+			},
+			wantClients: []*http.Client{cli2},
+			wantPending: 0,
+		},
+		{
+			name: "happy flow: client added again gets a single slot",
+			afterAdd: func(pool *Pool) {
+				pool.AddClientsAfter(10*time.Millisecond, cli1)
+				pool.AddClientsAfter(0, cli2)
+				time.Sleep(10 * time.Millisecond)
+				pool.AddClientsAfter(0, cli2)
+			},
+			wantClients: []*http.Client{cli1, cli2},
+			wantPending: 0,
+		},
+		{
+			name: "happy flow: longest delay wins",
+			afterAdd: func(pool *Pool) {
+				pool.AddClientsAfter(time.Second, cli1)
+			},
+			wantClients: []*http.Client{cli2},
+			wantPending: 1,
+		},
+		{
+			name: "happy flow: closed pool adds nothing back",
+			afterAdd: func(pool *Pool) {
+				pool.Close(context.Background())
+			},
+			wantClients: nil,
+			wantPending: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			defer pool.Close(context.Background())
+			pool.AddClientsAfter(50*time.Millisecond, cli1, cli2)
+			assert.Empty(t, getClients(pool))
+
+			tt.afterAdd(pool)
+			time.Sleep(100 * time.Millisecond)
+
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+			assert.ElementsMatch(t, tt.wantClients, pool.clients)
+			assert.Len(t, pool.pending, tt.wantPending)
+		})
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	t.Parallel()
+
+	t.Run("happy flow: wakes waiters with ErrPoolClosed", func(t *testing.T) {
+		t.Parallel()
+
+		pool := NewClientPool()
+		errCh := make(chan error)
+		go func() {
+			_, err := pool.AcquireClient(context.Background(), &http.Request{})
+			errCh <- err
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, pool.Close(context.Background()))
+
+		err := <-errCh
+		assert.ErrorIs(t, err, ErrPoolClosed)
+		assert.ErrorIs(t, err, ErrNoClientAvailable)
+
+		_, err = pool.AcquireClient(context.Background(), &http.Request{})
+		assert.ErrorIs(t, err, ErrPoolClosed)
+
+		pool.AddClients(http.DefaultClient)
+		assert.Nil(t, pool.GetClient(&http.Request{}))
+	})
+
+	t.Run("happy flow: waits for in-flight requests", func(t *testing.T) {
+		t.Parallel()
+
+		pool := NewClientPool()
+		pool.AddClients(http.DefaultClient)
+		cli := pool.GetClient(&http.Request{})
+
+		time.AfterFunc(50*time.Millisecond, func() {
+			pool.ReportResult(RequestResult{Client: cli})
+		})
+
+		start := time.Now()
+		assert.NoError(t, pool.Close(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("error flow: context done before in-flight requests finish", func(t *testing.T) {
+		t.Parallel()
+
+		pool := NewClientPool()
+		pool.AddClients(http.DefaultClient)
+		pool.GetClient(&http.Request{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, pool.Close(ctx), context.DeadlineExceeded)
+	})
+}
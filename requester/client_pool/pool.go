@@ -1,50 +1,588 @@
 package pool
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/htchan/goclient"
 )
 
+// latencyWeight is the smoothing factor of the latency moving average: every
+// new sample counts for 1/latencyWeight of the average.
+const latencyWeight = 4
+
+// ErrNoClientAvailable is returned when no client can be acquired from the
+// pool, either because the caller gave up waiting or because every client
+// has been removed from the pool.
+var ErrNoClientAvailable = errors.New("no client available in pool")
+
+// ErrPoolClosed is returned when acquiring a client from a closed pool. It is
+// always wrapped together with ErrNoClientAvailable.
+var ErrPoolClosed = errors.New("client pool is closed")
+
+// ClientPool hands out the clients requests are sent with. The requesters
+// and recorders of this package also make use of the optional interfaces
+// below when the pool implements them, as *Pool does.
 type ClientPool interface {
 	AddClients(...*http.Client)
 	GetClient(req *http.Request) *http.Client
 }
 
-type clientPoolImpl struct {
+// ClientAcquirer is implemented by pools that can give up waiting for a
+// client.
+type ClientAcquirer interface {
+	// AcquireClient waits for a client until ctx is done, and returns
+	// ErrNoClientAvailable on failure.
+	AcquireClient(ctx context.Context, req *http.Request) (*http.Client, error)
+}
+
+// ResultReporter is implemented by pools that track the requests made with
+// the clients they hand out.
+type ResultReporter interface {
+	// ReportResult tells the pool how a request made with a client it handed
+	// out went, so that it can track in-flight requests and latency.
+	ReportResult(result RequestResult)
+}
+
+// RequestResult describes a finished request made with a pooled client.
+type RequestResult struct {
+	Client   *http.Client
+	Request  *http.Request
+	Response *http.Response
+	Err      error
+	Latency  time.Duration
+}
+
+// clientState is what the pool tracks about each of its clients.
+type clientState struct {
+	// index is the order in which the client joined the pool.
+	index    int
+	inFlight int
+	// latency is a moving average of recent request latency.
+	latency time.Duration
+
+	requests            uint64
+	failures            uint64
+	consecutiveFailures int
+	totalLatency        time.Duration
+	lastError           error
+}
+
+// ClientRemover is implemented by pools that can forget clients.
+type ClientRemover interface {
+	RemoveClients(...*http.Client)
+}
+
+// DelayedAdder is implemented by pools that can add clients after a delay
+// and cancel the addition if the clients are removed in the meantime.
+type DelayedAdder interface {
+	AddClientsAfter(delay time.Duration, clients ...*http.Client)
+}
+
+// Quarantiner is implemented by pools that can take clients out of rotation
+// until they are revived.
+type Quarantiner interface {
+	QuarantineClients(...*http.Client)
+	ReviveClients(...*http.Client)
+	QuarantinedClients() []*http.Client
+}
+
+// ClientConfigurer is implemented by pools that accept per-client settings.
+type ClientConfigurer interface {
+	ConfigureClient(cli *http.Client, opts ...ClientOption)
+}
+
+// ClientStatsReporter is implemented by pools that track statistics about
+// their clients.
+type ClientStatsReporter interface {
+	ClientStats(cli *http.Client) (ClientStats, bool)
+}
+
+// Option configures the client pool.
+type Option func(*Pool)
+
+// WithStrategy sets how a client is picked among the available ones. The
+// default hands out clients in the order they became available.
+func WithStrategy(strategy Strategy) Option {
+	return func(pool *Pool) {
+		pool.strategy = strategy
+	}
+}
+
+// WithFailureValidator sets which request results count as failures in the
+// client statistics. The default counts requests that returned an error.
+func WithFailureValidator(isFailure goclient.ResultValidator) Option {
+	return func(pool *Pool) {
+		pool.isFailure = isFailure
+	}
+}
+
+// WithAcquireTimeout bounds how long AcquireClient waits for a client. Zero
+// means wait until the context is done. GetClient is not bounded.
+func WithAcquireTimeout(timeout time.Duration) Option {
+	return func(pool *Pool) {
+		pool.acquireTimeout = timeout
+	}
+}
+
+// Pool is the ClientPool of this package. It implements every optional pool
+// interface, and hands out each client to a single caller at a time, or to as
+// many as allowed with WithMaxConcurrency.
+type Pool struct {
 	mutex   *sync.Mutex
 	cond    *sync.Cond
 	clients []*http.Client
+
+	// members holds every client added to the pool and not removed since,
+	// whether it is available or not.
+	members   map[*http.Client]*clientState
+	nextIndex int
+	// quarantined holds clients taken out of rotation until revived.
+	quarantined map[*http.Client]*clientState
+	// removed counts the unreported requests of clients removed while in
+	// flight, so that the recorders of these requests cannot add them back.
+	removed map[*http.Client]int
+	// configs holds the settings set with ConfigureClient.
+	configs map[*http.Client]*clientConfig
+	// sessions pins session keys to clients, see WithSession.
+	sessions   map[string]*session
+	sessionTTL time.Duration
+	// drained is set once the last member has been removed and nothing is
+	// left in quarantine, after which waiting for a client is pointless.
+	drained bool
+
+	// pending holds the AddClientsAfter calls that have not fired, at most one
+	// per client.
+	pending map[*http.Client]*pendingAdd
+	closed  bool
+
+	// waiters is the number of callers waiting for a client, and dropped the
+	// number of clients removed since the pool was created.
+	waiters int
+	dropped int
+
+	acquireTimeout time.Duration
+	strategy       Strategy
+	isFailure      goclient.ResultValidator
 }
 
-func NewClientPool() ClientPool {
+// NewClientPool creates an empty pool.
+func NewClientPool(opts ...Option) *Pool {
 	mutex := new(sync.Mutex)
-	return &clientPoolImpl{
-		mutex:   mutex,
-		cond:    sync.NewCond(mutex),
-		clients: []*http.Client{},
+	pool := &Pool{
+		mutex:       mutex,
+		cond:        sync.NewCond(mutex),
+		clients:     []*http.Client{},
+		members:     map[*http.Client]*clientState{},
+		quarantined: map[*http.Client]*clientState{},
+		removed:     map[*http.Client]int{},
+		configs:     map[*http.Client]*clientConfig{},
+		sessions:    map[string]*session{},
+		pending:     map[*http.Client]*pendingAdd{},
 	}
+
+	for _, opt := range opts {
+		opt(pool)
+	}
+
+	return pool
 }
 
-func (pool *clientPoolImpl) AddClients(clients ...*http.Client) {
+// AddClients makes the given clients available.
+func (pool *Pool) AddClients(clients ...*http.Client) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
+	pool.addClients(clients)
+}
+
+// addClients makes the clients available. Must be called with mutex held.
+func (pool *Pool) addClients(clients []*http.Client) {
+	if pool.closed {
+		return
+	}
+	if pool.members == nil {
+		pool.members = make(map[*http.Client]*clientState)
+	}
+
 	for _, cli := range clients {
+		if pool.retired(cli) {
+			continue
+		}
+		if pool.concurrency(cli) > 1 && slices.Contains(pool.clients, cli) {
+			// a client serving concurrent requests is available once
+			continue
+		}
+
 		pool.clients = append(pool.clients, cli)
-		pool.cond.Signal()
+		if _, ok := pool.members[cli]; !ok {
+			pool.members[cli] = &clientState{index: pool.nextIndex}
+			pool.nextIndex++
+		}
+		pool.drained = false
+	}
+	pool.cond.Broadcast()
+}
+
+// retired reports whether adding cli must be ignored: it is quarantined, and
+// only ReviveClients puts it back into rotation, or it was removed while in
+// flight. Must be called with mutex held.
+func (pool *Pool) retired(cli *http.Client) bool {
+	_, isRemoved := pool.removed[cli]
+	_, isQuarantined := pool.quarantined[cli]
+	return isRemoved || isQuarantined
+}
+
+// RemoveClients forgets the given clients, including quarantined ones.
+// Clients with requests in flight cannot be added back until these requests
+// have been reported, so that recorders do not undo the removal. Waiters are
+// failed with ErrNoClientAvailable once every client has been removed.
+func (pool *Pool) RemoveClients(clients ...*http.Client) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.removed == nil {
+		pool.removed = make(map[*http.Client]int)
+	}
+
+	for _, cli := range clients {
+		state, ok := pool.members[cli]
+		if !ok {
+			state, ok = pool.quarantined[cli]
+		}
+		if ok {
+			pool.dropped++
+			if state.inFlight > 0 {
+				pool.removed[cli] = state.inFlight
+			}
+		}
+
+		delete(pool.members, cli)
+		delete(pool.quarantined, cli)
+		delete(pool.configs, cli)
+	}
+	pool.takeAvailable(clients)
+	pool.stopPending(func(cli *http.Client) bool { return slices.Contains(clients, cli) })
+	pool.unpin(clients)
+
+	if len(clients) > 0 {
+		pool.checkDrained()
+	}
+}
+
+// QuarantineClients takes the given clients out of rotation until they are
+// revived, e.g. by a HealthChecker. Adding them back in the meantime, e.g.
+// once their in-flight requests finish, has no effect.
+func (pool *Pool) QuarantineClients(clients ...*http.Client) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.quarantined == nil {
+		pool.quarantined = make(map[*http.Client]*clientState)
+	}
+
+	for _, cli := range clients {
+		state, ok := pool.members[cli]
+		if !ok {
+			state = &clientState{index: pool.nextIndex}
+			pool.nextIndex++
+		}
+		delete(pool.members, cli)
+		pool.quarantined[cli] = state
 	}
+	pool.takeAvailable(clients)
+	pool.stopPending(func(cli *http.Client) bool { return slices.Contains(clients, cli) })
+	pool.unpin(clients)
 }
 
-func (pool *clientPoolImpl) GetClient(req *http.Request) *http.Client {
+// ReviveClients puts quarantined clients back into rotation. A client at its
+// concurrency limit becomes available once added back, e.g. by the recorder
+// of one of its requests in flight, so that it never serves more requests
+// than allowed.
+func (pool *Pool) ReviveClients(clients ...*http.Client) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	for len(pool.clients) == 0 {
-		pool.cond.Wait()
+	if pool.members == nil {
+		pool.members = make(map[*http.Client]*clientState)
 	}
 
-	cli := pool.clients[0]
-	pool.clients = pool.clients[1:]
+	for _, cli := range clients {
+		state, ok := pool.quarantined[cli]
+		if !ok {
+			continue
+		}
+
+		delete(pool.quarantined, cli)
+		pool.members[cli] = state
+		if state.inFlight < pool.concurrency(cli) {
+			pool.clients = append(pool.clients, cli)
+		}
+		pool.drained = false
+	}
+	pool.cond.Broadcast()
+}
+
+// QuarantinedClients lists the clients currently in quarantine.
+func (pool *Pool) QuarantinedClients() []*http.Client {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	clients := make([]*http.Client, 0, len(pool.quarantined))
+	for cli := range pool.quarantined {
+		clients = append(clients, cli)
+	}
+	slices.SortFunc(clients, func(a, b *http.Client) int {
+		return pool.quarantined[a].index - pool.quarantined[b].index
+	})
+
+	return clients
+}
+
+// takeAvailable drops every slot of the given clients from the available
+// ones. Must be called with mutex held.
+func (pool *Pool) takeAvailable(clients []*http.Client) {
+	pool.clients = slices.DeleteFunc(pool.clients, func(cli *http.Client) bool {
+		return slices.Contains(clients, cli)
+	})
+}
+
+// checkDrained marks the pool as drained and wakes up the waiters once no
+// client is left. Must be called with mutex held.
+func (pool *Pool) checkDrained() {
+	if len(pool.members) == 0 && len(pool.quarantined) == 0 {
+		pool.drained = true
+		pool.cond.Broadcast()
+	}
+}
+
+// GetClient waits until a client is available, as it always has: neither the
+// acquire timeout, nor the removal of every client, nor a tag no client has
+// stops the wait. It returns nil only once the pool is closed. Use
+// AcquireClient to give up waiting.
+func (pool *Pool) GetClient(req *http.Request) *http.Client {
+	cli, _ := pool.acquire(context.Background(), req, false)
+	return cli
+}
+
+// AcquireClient waits for a client until ctx is done or the acquire timeout
+// expires, and returns ErrNoClientAvailable on failure, including once every
+// client has been removed or the pool is closed.
+func (pool *Pool) AcquireClient(ctx context.Context, req *http.Request) (*http.Client, error) {
+	return pool.acquire(ctx, req, true)
+}
+
+// acquire waits for a client until ctx is done. If canFail is set, it also
+// gives up after the acquire timeout or as soon as waiting is pointless.
+func (pool *Pool) acquire(ctx context.Context, req *http.Request, canFail bool) (*http.Client, error) {
+	if canFail && pool.acquireTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pool.acquireTimeout)
+		defer cancel()
+	}
+
+	// wake up the waiters so that this one notices ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+
+		pool.cond.Broadcast()
+	})
+	defer stop()
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	tags := requiredTags(req)
+	session := sessionKey(req)
+
+	var cli *http.Client
+	for {
+		if pool.closed {
+			return nil, fmt.Errorf("%w: %w", ErrNoClientAvailable, ErrPoolClosed)
+		}
+
+		var wait time.Duration
+		if len(pool.clients) > 0 {
+			now := time.Now()
+			match := pool.matcher(tags, pool.pinned(session, tags, now))
+			if cli = pool.pick(req, match, now); cli != nil {
+				pool.pin(session, cli, now)
+				break
+			}
+			wait = pool.nextAllowed(match, now)
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoClientAvailable, context.Cause(ctx))
+		}
+		if canFail && pool.drained {
+			return nil, fmt.Errorf("%w: every client has been removed", ErrNoClientAvailable)
+		}
+		if canFail && len(tags) > 0 && len(pool.members)+len(pool.quarantined) > 0 && !pool.anyHasTags(tags) {
+			// waiting is pointless until clients are reconfigured
+			return nil, fmt.Errorf("%w: no client has tags %v", ErrNoClientAvailable, tags)
+		}
+
+		pool.waitFor(wait)
+	}
+
+	if state, ok := pool.members[cli]; ok {
+		state.inFlight++
+		if state.inFlight < pool.concurrency(cli) {
+			// the client keeps serving other requests up to its limit
+			pool.clients = append(pool.clients, cli)
+		}
+	}
+
+	return cli, nil
+}
+
+// waitFor waits until the pool changes, or until wait has passed if it is
+// positive. Must be called with mutex held.
+func (pool *Pool) waitFor(wait time.Duration) {
+	if wait > 0 {
+		// wake up once the first rate limited client is allowed a request
+		timer := time.AfterFunc(wait, func() {
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+
+			pool.cond.Broadcast()
+		})
+		defer timer.Stop()
+	}
+
+	pool.waiters++
+	pool.cond.Wait()
+	pool.waiters--
+}
+
+// matcher returns whether a client has tags and, if pinned is not nil, is
+// the pinned one. Must be called with mutex held.
+func (pool *Pool) matcher(tags []string, pinned *http.Client) func(cli *http.Client) bool {
+	return func(cli *http.Client) bool {
+		return (pinned == nil || cli == pinned) && pool.hasTags(cli, tags)
+	}
+}
+
+// pick takes a matching client allowed a request at now out of the available
+// ones using the pool strategy, and returns nil if there is none. Must be
+// called with mutex held.
+func (pool *Pool) pick(req *http.Request, match func(*http.Client) bool, now time.Time) *http.Client {
+	candidates := pool.candidates(match, now)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	picked := 0
+	if pool.strategy != nil {
+		if i := pool.strategy(req, candidates); i >= 0 && i < len(candidates) {
+			picked = i
+		}
+	}
+
+	cli := candidates[picked].Client
+	i := slices.Index(pool.clients, cli)
+	pool.clients = slices.Delete(pool.clients, i, i+1)
+	if config, ok := pool.configs[cli]; ok && config.budget != nil {
+		config.budget.take(now)
+	}
 
 	return cli
 }
+
+// candidates lists the distinct available matching clients allowed a request
+// at now, in the order they became available. Must be called with mutex held.
+func (pool *Pool) candidates(match func(*http.Client) bool, now time.Time) []Candidate {
+	candidates := make([]Candidate, 0, len(pool.clients))
+	seen := make(map[*http.Client]struct{}, len(pool.clients))
+	for _, cli := range pool.clients {
+		if _, ok := seen[cli]; ok {
+			continue
+		}
+		seen[cli] = struct{}{}
+		if !match(cli) || pool.untilAllowed(cli, now) > 0 {
+			continue
+		}
+
+		candidate := Candidate{Client: cli, Label: pool.config(cli).label}
+		if state, ok := pool.members[cli]; ok {
+			candidate.Index = state.index
+			candidate.InFlight = state.inFlight
+			candidate.Latency = state.latency
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// nextAllowed returns how long until an available matching client is allowed
+// a request, zero if no such client is available. Must be called with mutex
+// held.
+func (pool *Pool) nextAllowed(match func(*http.Client) bool, now time.Time) time.Duration {
+	wait := time.Duration(0)
+	for _, cli := range pool.clients {
+		if !match(cli) {
+			continue
+		}
+		if untilAllowed := pool.untilAllowed(cli, now); wait == 0 || untilAllowed < wait {
+			wait = untilAllowed
+		}
+	}
+
+	return wait
+}
+
+// ReportResult records the outcome of a request made with a client of the
+// pool.
+func (pool *Pool) ReportResult(result RequestResult) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if inFlight, ok := pool.removed[result.Client]; ok {
+		if inFlight <= 1 {
+			delete(pool.removed, result.Client)
+		} else {
+			pool.removed[result.Client] = inFlight - 1
+		}
+		return
+	}
+
+	state, ok := pool.members[result.Client]
+	if !ok {
+		state, ok = pool.quarantined[result.Client]
+	}
+	if !ok {
+		return
+	}
+
+	state.requests++
+	state.totalLatency += result.Latency
+	if pool.failed(result) {
+		state.failures++
+		state.consecutiveFailures++
+		state.lastError = result.Err
+	} else {
+		state.consecutiveFailures = 0
+	}
+
+	if state.inFlight > 0 {
+		state.inFlight--
+	}
+	if pool.closed {
+		// Close may be waiting for the last in-flight request
+		pool.cond.Broadcast()
+	}
+	if state.latency == 0 {
+		state.latency = result.Latency
+	} else {
+		// exponentially weighted moving average favouring recent requests
+		state.latency = (state.latency*(latencyWeight-1) + result.Latency) / latencyWeight
+	}
+}
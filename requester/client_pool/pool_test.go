@@ -1,7 +1,10 @@
 package pool
 
 import (
+	"context"
+	"maps"
 	"net/http"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -14,14 +17,37 @@ func TestNewClientPool(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		wantClientPool clientPoolImpl
+		opts           []Option
+		wantClientPool Pool
 	}{
 		{
 			name: "happy flow",
-			wantClientPool: clientPoolImpl{
-				mutex:   new(sync.Mutex),
-				cond:    sync.NewCond(new(sync.Mutex)),
-				clients: []*http.Client{},
+			wantClientPool: Pool{
+				mutex:       new(sync.Mutex),
+				cond:        sync.NewCond(new(sync.Mutex)),
+				clients:     []*http.Client{},
+				members:     map[*http.Client]*clientState{},
+				quarantined: map[*http.Client]*clientState{},
+				removed:     map[*http.Client]int{},
+				configs:     map[*http.Client]*clientConfig{},
+				sessions:    map[string]*session{},
+				pending:     map[*http.Client]*pendingAdd{},
+			},
+		},
+		{
+			name: "happy flow: with acquire timeout",
+			opts: []Option{WithAcquireTimeout(time.Second)},
+			wantClientPool: Pool{
+				mutex:          new(sync.Mutex),
+				cond:           sync.NewCond(new(sync.Mutex)),
+				clients:        []*http.Client{},
+				members:        map[*http.Client]*clientState{},
+				quarantined:    map[*http.Client]*clientState{},
+				removed:        map[*http.Client]int{},
+				configs:        map[*http.Client]*clientConfig{},
+				sessions:       map[string]*session{},
+				pending:        map[*http.Client]*pendingAdd{},
+				acquireTimeout: time.Second,
 			},
 		},
 	}
@@ -30,13 +56,13 @@ func TestNewClientPool(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			pool := NewClientPool()
+			pool := NewClientPool(tt.opts...)
 			assert.Equal(t, &tt.wantClientPool, pool)
 		})
 	}
 }
 
-func TestClientPoolImpl_AddClients(t *testing.T) {
+func TestPool_AddClients(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
@@ -75,7 +101,7 @@ func TestClientPoolImpl_AddClients(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			pool := &clientPoolImpl{
+			pool := &Pool{
 				mutex:   new(sync.Mutex),
 				cond:    sync.NewCond(new(sync.Mutex)),
 				clients: tt.initialClients,
@@ -86,18 +112,18 @@ func TestClientPoolImpl_AddClients(t *testing.T) {
 	}
 }
 
-func TestClientPoolImpl_GetClient(t *testing.T) {
+func TestPool_GetClient(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
 		name               string
-		addClientsFunc     func(pool *clientPoolImpl)
+		addClientsFunc     func(pool *Pool)
 		wantClient         *http.Client
 		wantPoolClients    []*http.Client
 		wantWithinDuration time.Duration
 	}{
 		{
 			name: "happy flow: get first client from pool",
-			addClientsFunc: func(pool *clientPoolImpl) {
+			addClientsFunc: func(pool *Pool) {
 				pool.AddClients(http.DefaultClient, &http.Client{})
 			},
 			wantClient:         http.DefaultClient,
@@ -106,7 +132,7 @@ func TestClientPoolImpl_GetClient(t *testing.T) {
 		},
 		{
 			name: "edge case: wait until client available",
-			addClientsFunc: func(pool *clientPoolImpl) {
+			addClientsFunc: func(pool *Pool) {
 				go func() {
 					time.Sleep(100 * time.Millisecond)
 					pool.AddClients(http.DefaultClient)
@@ -125,7 +151,7 @@ func TestClientPoolImpl_GetClient(t *testing.T) {
 			start := time.Now()
 
 			mu := new(sync.Mutex)
-			pool := &clientPoolImpl{
+			pool := &Pool{
 				mutex: mu,
 				cond:  sync.NewCond(mu),
 			}
@@ -138,3 +164,508 @@ func TestClientPoolImpl_GetClient(t *testing.T) {
 		})
 	}
 }
+
+func TestPool_RemoveClients(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2 := &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name            string
+		initialClients  []*http.Client
+		clientsToRemove []*http.Client
+		wantClients     []*http.Client
+		wantMembers     []*http.Client
+		wantDrained     bool
+	}{
+		{
+			name:            "happy flow: remove available client",
+			initialClients:  []*http.Client{cli1, cli2, cli1},
+			clientsToRemove: []*http.Client{cli1},
+			wantClients:     []*http.Client{cli2},
+			wantMembers:     []*http.Client{cli2},
+			wantDrained:     false,
+		},
+		{
+			name:            "happy flow: remove every client",
+			initialClients:  []*http.Client{cli1, cli2},
+			clientsToRemove: []*http.Client{cli1, cli2},
+			wantClients:     []*http.Client{},
+			wantMembers:     []*http.Client{},
+			wantDrained:     true,
+		},
+		{
+			name:            "edge case: remove unknown client",
+			initialClients:  []*http.Client{cli1},
+			clientsToRemove: []*http.Client{cli2},
+			wantClients:     []*http.Client{cli1},
+			wantMembers:     []*http.Client{cli1},
+			wantDrained:     false,
+		},
+		{
+			name:            "edge case: remove nothing from empty pool",
+			initialClients:  nil,
+			clientsToRemove: nil,
+			wantClients:     []*http.Client{},
+			wantMembers:     []*http.Client{},
+			wantDrained:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.AddClients(tt.initialClients...)
+			pool.RemoveClients(tt.clientsToRemove...)
+
+			assert.Equal(t, tt.wantClients, pool.clients)
+			assert.ElementsMatch(t, tt.wantMembers, slices.Collect(maps.Keys(pool.members)))
+			assert.Equal(t, tt.wantDrained, pool.drained)
+		})
+	}
+}
+
+func TestPool_AcquireClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		opts               []Option
+		ctxTimeout         time.Duration
+		setupFunc          func(pool *Pool)
+		wantClient         *http.Client
+		wantErr            error
+		wantWithinDuration time.Duration
+	}{
+		{
+			name: "happy flow: client available",
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+			},
+			wantClient:         http.DefaultClient,
+			wantWithinDuration: 50 * time.Millisecond,
+		},
+		{
+			name:       "error flow: context deadline exceeded",
+			ctxTimeout: 50 * time.Millisecond,
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.GetClient(&http.Request{})
+			},
+			wantErr:            context.DeadlineExceeded,
+			wantWithinDuration: 150 * time.Millisecond,
+		},
+		{
+			name: "error flow: acquire timeout",
+			opts: []Option{WithAcquireTimeout(50 * time.Millisecond)},
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.GetClient(&http.Request{})
+			},
+			wantErr:            ErrNoClientAvailable,
+			wantWithinDuration: 150 * time.Millisecond,
+		},
+		{
+			name: "error flow: every client removed",
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.RemoveClients(http.DefaultClient)
+			},
+			wantErr:            ErrNoClientAvailable,
+			wantWithinDuration: 50 * time.Millisecond,
+		},
+		{
+			name: "error flow: waiter woken when last client is removed",
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				cli := pool.GetClient(&http.Request{})
+				time.AfterFunc(50*time.Millisecond, func() { pool.RemoveClients(cli) })
+			},
+			wantErr:            ErrNoClientAvailable,
+			wantWithinDuration: 150 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			pool := NewClientPool(tt.opts...)
+			tt.setupFunc(pool)
+
+			gotClient, err := pool.AcquireClient(ctx, &http.Request{})
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, ErrNoClientAvailable)
+			}
+			assert.Equal(t, tt.wantClient, gotClient)
+			assert.GreaterOrEqual(t, tt.wantWithinDuration, time.Since(start))
+		})
+	}
+}
+
+func TestPool_AcquireClient_CancelledWaiterDoesNotLoseClient(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelledDone := make(chan error)
+	go func() {
+		_, err := pool.AcquireClient(ctx, &http.Request{})
+		cancelledDone <- err
+	}()
+
+	waiterDone := make(chan *http.Client)
+	go func() {
+		cli, _ := pool.AcquireClient(context.Background(), &http.Request{})
+		waiterDone <- cli
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-cancelledDone, context.Canceled)
+
+	pool.AddClients(http.DefaultClient)
+	assert.Equal(t, http.DefaultClient, <-waiterDone)
+}
+
+func TestPool_GetClient_KeepsWaiting(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		setupFunc func(pool *Pool)
+		req       *http.Request
+	}{
+		{
+			name: "happy flow: every client removed",
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.RemoveClients(http.DefaultClient)
+			},
+			req: &http.Request{},
+		},
+		{
+			name: "happy flow: acquire timeout ignored",
+			opts: []Option{WithAcquireTimeout(10 * time.Millisecond)},
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.GetClient(&http.Request{})
+			},
+			req: &http.Request{},
+		},
+		{
+			name: "happy flow: no client has the tags yet",
+			setupFunc: func(pool *Pool) {
+				pool.AddClients(&http.Client{})
+			},
+			req: (&http.Request{}).WithContext(WithRequiredTags(context.Background(), "eu")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool(tt.opts...)
+			tt.setupFunc(pool)
+
+			cli := &http.Client{}
+			time.AfterFunc(50*time.Millisecond, func() {
+				pool.ConfigureClient(cli, WithTags("eu"))
+				pool.AddClients(cli)
+			})
+
+			assert.Equal(t, cli, pool.GetClient(tt.req))
+		})
+	}
+}
+
+func TestPool_GetClient_Closed(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool()
+	time.AfterFunc(50*time.Millisecond, func() { pool.Close(context.Background()) })
+
+	assert.Nil(t, pool.GetClient(&http.Request{}))
+}
+
+func TestPool_AcquireClient_Strategy(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2, cli3 := &http.Client{}, &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name            string
+		strategy        Strategy
+		initialClients  []*http.Client
+		wantCandidates  []*http.Client
+		wantClient      *http.Client
+		wantPoolClients []*http.Client
+	}{
+		{
+			name:            "happy flow: default is FIFO",
+			strategy:        nil,
+			initialClients:  []*http.Client{cli1, cli2, cli3},
+			wantClient:      cli1,
+			wantPoolClients: []*http.Client{cli2, cli3},
+		},
+		{
+			name:            "happy flow: candidates are deduplicated",
+			strategy:        func(_ *http.Request, candidates []Candidate) int { return 1 },
+			initialClients:  []*http.Client{cli1, cli2, cli1, cli2, cli3},
+			wantCandidates:  []*http.Client{cli1, cli2, cli3},
+			wantClient:      cli2,
+			wantPoolClients: []*http.Client{cli1, cli1, cli2, cli3},
+		},
+		{
+			name:            "edge case: out of range pick falls back to first",
+			strategy:        func(_ *http.Request, candidates []Candidate) int { return 10 },
+			initialClients:  []*http.Client{cli1, cli2},
+			wantCandidates:  []*http.Client{cli1, cli2},
+			wantClient:      cli1,
+			wantPoolClients: []*http.Client{cli2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotCandidates []*http.Client
+			var strategy Strategy
+			if tt.strategy != nil {
+				strategy = func(req *http.Request, candidates []Candidate) int {
+					for _, candidate := range candidates {
+						gotCandidates = append(gotCandidates, candidate.Client)
+					}
+					return tt.strategy(req, candidates)
+				}
+			}
+
+			pool := NewClientPool(WithStrategy(strategy))
+			pool.AddClients(tt.initialClients...)
+
+			gotClient, err := pool.AcquireClient(context.Background(), &http.Request{})
+			assert.NoError(t, err)
+			assert.Same(t, tt.wantClient, gotClient)
+			assert.Equal(t, tt.wantCandidates, gotCandidates)
+			assert.Equal(t, tt.wantPoolClients, pool.clients)
+			assert.Equal(t, 1, pool.members[tt.wantClient].inFlight)
+		})
+	}
+}
+
+func TestPool_ReportResult(t *testing.T) {
+	t.Parallel()
+
+	cli, unknown := &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name         string
+		latencies    []time.Duration
+		client       *http.Client
+		wantInFlight int
+		wantLatency  time.Duration
+	}{
+		{
+			name:         "happy flow: first sample sets latency",
+			latencies:    []time.Duration{100 * time.Millisecond},
+			client:       cli,
+			wantInFlight: 1,
+			wantLatency:  100 * time.Millisecond,
+		},
+		{
+			name:         "happy flow: later samples are averaged",
+			latencies:    []time.Duration{100 * time.Millisecond, 500 * time.Millisecond},
+			client:       cli,
+			wantInFlight: 0,
+			wantLatency:  200 * time.Millisecond,
+		},
+		{
+			name:         "edge case: in flight never goes negative",
+			latencies:    []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond},
+			client:       cli,
+			wantInFlight: 0,
+			wantLatency:  time.Millisecond,
+		},
+		{
+			name:         "edge case: unknown client is ignored",
+			latencies:    []time.Duration{time.Second},
+			client:       unknown,
+			wantInFlight: 2,
+			wantLatency:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.AddClients(cli, cli)
+			pool.GetClient(&http.Request{})
+			pool.GetClient(&http.Request{})
+
+			for _, latency := range tt.latencies {
+				pool.ReportResult(RequestResult{Client: tt.client, Latency: latency})
+			}
+
+			assert.Equal(t, tt.wantInFlight, pool.members[cli].inFlight)
+			assert.Equal(t, tt.wantLatency, pool.members[cli].latency)
+		})
+	}
+}
+
+func TestPool_Quarantine(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2, cli3 := &http.Client{}, &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name            string
+		initialClients  []*http.Client
+		quarantine      []*http.Client
+		revive          []*http.Client
+		remove          []*http.Client
+		wantClients     []*http.Client
+		wantQuarantined []*http.Client
+		wantDrained     bool
+	}{
+		{
+			name:            "happy flow: quarantined client leaves rotation",
+			initialClients:  []*http.Client{cli1, cli2, cli1},
+			quarantine:      []*http.Client{cli1},
+			wantClients:     []*http.Client{cli2},
+			wantQuarantined: []*http.Client{cli1},
+		},
+		{
+			name:            "happy flow: quarantined clients listed in join order",
+			initialClients:  []*http.Client{cli1, cli2, cli3},
+			quarantine:      []*http.Client{cli3, cli1},
+			wantClients:     []*http.Client{cli2},
+			wantQuarantined: []*http.Client{cli1, cli3},
+		},
+		{
+			name:            "happy flow: revived client rejoins rotation once",
+			initialClients:  []*http.Client{cli1, cli2, cli1},
+			quarantine:      []*http.Client{cli1},
+			revive:          []*http.Client{cli1},
+			wantClients:     []*http.Client{cli2, cli1},
+			wantQuarantined: []*http.Client{},
+		},
+		{
+			name:            "edge case: reviving a client not in quarantine does nothing",
+			initialClients:  []*http.Client{cli1},
+			revive:          []*http.Client{cli2},
+			wantClients:     []*http.Client{cli1},
+			wantQuarantined: []*http.Client{},
+		},
+		{
+			name:            "edge case: removing quarantined client drains the pool",
+			initialClients:  []*http.Client{cli1},
+			quarantine:      []*http.Client{cli1},
+			remove:          []*http.Client{cli1},
+			wantClients:     []*http.Client{},
+			wantQuarantined: []*http.Client{},
+			wantDrained:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.AddClients(tt.initialClients...)
+			pool.QuarantineClients(tt.quarantine...)
+			pool.ReviveClients(tt.revive...)
+			pool.RemoveClients(tt.remove...)
+
+			assert.Equal(t, tt.wantClients, pool.clients)
+			assert.Equal(t, tt.wantQuarantined, pool.QuarantinedClients())
+			assert.Equal(t, tt.wantDrained, pool.drained)
+		})
+	}
+}
+
+func TestPool_AcquireClient_WaitsForRevival(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool()
+	pool.AddClients(http.DefaultClient)
+	pool.QuarantineClients(http.DefaultClient)
+
+	time.AfterFunc(50*time.Millisecond, func() { pool.ReviveClients(http.DefaultClient) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cli, err := pool.AcquireClient(ctx, &http.Request{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.DefaultClient, cli)
+}
+
+func TestPool_ReviveClients_InFlight(t *testing.T) {
+	t.Parallel()
+
+	acquire := func(pool *Pool) (*http.Client, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		return pool.AcquireClient(ctx, &http.Request{})
+	}
+
+	cli := &http.Client{}
+	pool := NewClientPool()
+	pool.AddClients(cli)
+
+	first, err := acquire(pool)
+	assert.NoError(t, err)
+	pool.QuarantineClients(cli)
+	pool.ReviveClients(cli)
+
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.Available)
+	assert.Equal(t, 1, stats.InUse)
+
+	// the revived client is still in use by the first request
+	_, err = acquire(pool)
+	assert.ErrorIs(t, err, ErrNoClientAvailable)
+
+	// the first request finishes and its recorder adds the client back
+	pool.AddClients(first)
+	pool.ReportResult(RequestResult{Client: first})
+
+	second, err := acquire(pool)
+	assert.NoError(t, err)
+	assert.Same(t, cli, second)
+
+	_, err = acquire(pool)
+	assert.ErrorIs(t, err, ErrNoClientAvailable)
+	assert.Equal(t, 1, pool.Stats().InUse)
+
+	closed := make(chan error)
+	go func() { closed <- pool.Close(context.Background()) }()
+
+	select {
+	case <-closed:
+		assert.Fail(t, "Close returned while a request is in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.ReportResult(RequestResult{Client: second})
+	assert.NoError(t, <-closed)
+}
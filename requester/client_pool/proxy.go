@@ -0,0 +1,266 @@
+package pool
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidProxy is returned when a proxy URL cannot be used.
+var ErrInvalidProxy = errors.New("invalid proxy url")
+
+// ProxyPool is a pool a ProxyPoolBuilder can keep in sync. *Pool implements
+// it.
+type ProxyPool interface {
+	ClientPool
+	ClientRemover
+	ClientConfigurer
+}
+
+// ProxyPoolBuilder keeps a pool in sync with a list of outbound proxies, with
+// one client per proxy.
+type ProxyPoolBuilder struct {
+	mutex sync.Mutex
+	pool  ProxyPool
+
+	transport *http.Transport
+	timeout   time.Duration
+	label     func(proxy *url.URL) string
+	// cookieJars, if set, gives every client its own cookie jar.
+	cookieJars *cookiejar.Options
+
+	// clients holds the client of every proxy in the current list, keyed by
+	// the normalised proxy URL.
+	clients map[string]proxyClient
+}
+
+type proxyClient struct {
+	proxy  *url.URL
+	client *http.Client
+}
+
+// ProxyOption configures the proxy pool builder.
+type ProxyOption func(*ProxyPoolBuilder)
+
+// WithTransportTemplate sets the transport cloned for every proxy client. The
+// clone's Proxy is always replaced by the proxy URL. The default is a clone
+// of http.DefaultTransport.
+func WithTransportTemplate(transport *http.Transport) ProxyOption {
+	return func(builder *ProxyPoolBuilder) {
+		builder.transport = transport
+	}
+}
+
+// WithClientTimeout sets the timeout of every proxy client.
+func WithClientTimeout(timeout time.Duration) ProxyOption {
+	return func(builder *ProxyPoolBuilder) {
+		builder.timeout = timeout
+	}
+}
+
+// WithProxyLabel sets how proxy clients are labelled. The default label is
+// the proxy URL with its password redacted.
+func WithProxyLabel(label func(proxy *url.URL) string) ProxyOption {
+	return func(builder *ProxyPoolBuilder) {
+		builder.label = label
+	}
+}
+
+// WithCookieJars gives every proxy client its own cookie jar created with
+// options, which may be nil. Combined with WithSession, a session keeps its
+// cookies for as long as it stays on the same proxy.
+func WithCookieJars(options *cookiejar.Options) ProxyOption {
+	return func(builder *ProxyPoolBuilder) {
+		if options == nil {
+			options = &cookiejar.Options{}
+		}
+		builder.cookieJars = options
+	}
+}
+
+// ProxyDiff lists the proxies added and removed by a reload.
+type ProxyDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// NewProxyPoolBuilder creates a builder adding proxy clients to pool.
+func NewProxyPoolBuilder(pool ProxyPool, opts ...ProxyOption) *ProxyPoolBuilder {
+	builder := &ProxyPoolBuilder{
+		pool:    pool,
+		label:   (*url.URL).Redacted,
+		clients: map[string]proxyClient{},
+	}
+
+	for _, opt := range opts {
+		opt(builder)
+	}
+
+	return builder
+}
+
+// Reload replaces the proxy list: clients are created and added for new
+// proxies and removed for proxies missing from the list, with their idle
+// connections closed, while clients of unchanged proxies are kept. Nothing
+// changes if any proxy is invalid.
+func (builder *ProxyPoolBuilder) Reload(proxies []string) (ProxyDiff, error) {
+	parsed := make(map[string]*url.URL, len(proxies))
+	order := make([]string, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxyURL, err := parseProxy(proxy)
+		if err != nil {
+			return ProxyDiff{}, err
+		}
+
+		key := proxyURL.String()
+		if _, ok := parsed[key]; ok {
+			continue
+		}
+		parsed[key] = proxyURL
+		order = append(order, key)
+	}
+
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	var diff ProxyDiff
+	removed := []*http.Client{}
+	for key, current := range builder.clients {
+		if _, ok := parsed[key]; !ok {
+			diff.Removed = append(diff.Removed, builder.label(current.proxy))
+			removed = append(removed, current.client)
+			delete(builder.clients, key)
+		}
+	}
+	slices.Sort(diff.Removed)
+
+	added := []*http.Client{}
+	for _, key := range order {
+		if _, ok := builder.clients[key]; ok {
+			continue
+		}
+
+		label := builder.label(parsed[key])
+		cli := builder.newClient(parsed[key])
+		builder.pool.ConfigureClient(cli, WithLabel(label))
+		builder.clients[key] = proxyClient{proxy: parsed[key], client: cli}
+		added = append(added, cli)
+		diff.Added = append(diff.Added, label)
+	}
+
+	if len(removed) > 0 {
+		builder.pool.RemoveClients(removed...)
+		// nothing reuses the connections of a removed proxy, requests still
+		// in flight keep theirs until they finish
+		for _, cli := range removed {
+			cli.CloseIdleConnections()
+		}
+	}
+	if len(added) > 0 {
+		builder.pool.AddClients(added...)
+	}
+
+	return diff, nil
+}
+
+// ReloadFile reloads the proxy list from a file, see ReadProxies.
+func (builder *ProxyPoolBuilder) ReloadFile(path string) (ProxyDiff, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ProxyDiff{}, fmt.Errorf("open proxy file: %w", err)
+	}
+	defer file.Close()
+
+	proxies, err := ReadProxies(file)
+	if err != nil {
+		return ProxyDiff{}, err
+	}
+
+	return builder.Reload(proxies)
+}
+
+// ReloadEnv reloads the proxy list from a comma or whitespace separated
+// environment variable.
+func (builder *ProxyPoolBuilder) ReloadEnv(key string) (ProxyDiff, error) {
+	return builder.Reload(ProxiesFromEnv(key))
+}
+
+// Client returns the client of a proxy in the current list.
+func (builder *ProxyPoolBuilder) Client(proxy string) (*http.Client, bool) {
+	proxyURL, err := parseProxy(proxy)
+	if err != nil {
+		return nil, false
+	}
+
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	current, ok := builder.clients[proxyURL.String()]
+	return current.client, ok
+}
+
+func (builder *ProxyPoolBuilder) newClient(proxy *url.URL) *http.Client {
+	var transport *http.Transport
+	if builder.transport != nil {
+		transport = builder.transport.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+
+	cli := &http.Client{Transport: transport, Timeout: builder.timeout}
+	if builder.cookieJars != nil {
+		// cookiejar.New never fails
+		cli.Jar, _ = cookiejar.New(builder.cookieJars)
+	}
+
+	return cli
+}
+
+// ReadProxies reads one proxy URL per line, skipping blank lines and lines
+// starting with #.
+func ReadProxies(r io.Reader) ([]string, error) {
+	proxies := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxies = append(proxies, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read proxies: %w", err)
+	}
+
+	return proxies, nil
+}
+
+// ProxiesFromEnv splits an environment variable into proxy URLs, separated
+// by commas or whitespace.
+func ProxiesFromEnv(key string) []string {
+	return strings.FieldsFunc(os.Getenv(key), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(proxy))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidProxy, err)
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("%w: %q needs a scheme and a host", ErrInvalidProxy, proxy)
+	}
+
+	return proxyURL, nil
+}
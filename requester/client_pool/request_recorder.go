@@ -12,10 +12,7 @@ type RequestRecorder func(pool ClientPool, cli *http.Client, req *http.Request,
 
 func NewRequestRecorderAlwaysAddClientBack(cooldownInterval time.Duration) RequestRecorder {
 	return func(pool ClientPool, cli *http.Client, req *http.Request, resp *http.Response, err error) {
-		go func() {
-			time.Sleep(cooldownInterval)
-			pool.AddClients(cli)
-		}()
+		addClientsAfter(pool, cooldownInterval, cli)
 	}
 }
 
@@ -24,6 +21,41 @@ func NewRequestRecorderDropClientForContinueFailed(
 	failureThreshold int,
 	failureCooldownInterval time.Duration,
 	successCooldownInterval time.Duration,
+) RequestRecorder {
+	return newRequestRecorderForContinueFailed(
+		isRequestFail,
+		failureThreshold,
+		failureCooldownInterval,
+		successCooldownInterval,
+		func(pool ClientPool, cli *http.Client) { removeClients(pool, cli) },
+	)
+}
+
+// NewRequestRecorderQuarantineClientForContinueFailed works like
+// NewRequestRecorderDropClientForContinueFailed, but quarantines the failing
+// client instead of dropping it, so that a HealthChecker can revive it. The
+// client is dropped if the pool is not a Quarantiner.
+func NewRequestRecorderQuarantineClientForContinueFailed(
+	isRequestFail goclient.ResultValidator,
+	failureThreshold int,
+	failureCooldownInterval time.Duration,
+	successCooldownInterval time.Duration,
+) RequestRecorder {
+	return newRequestRecorderForContinueFailed(
+		isRequestFail,
+		failureThreshold,
+		failureCooldownInterval,
+		successCooldownInterval,
+		func(pool ClientPool, cli *http.Client) { quarantineClients(pool, cli) },
+	)
+}
+
+func newRequestRecorderForContinueFailed(
+	isRequestFail goclient.ResultValidator,
+	failureThreshold int,
+	failureCooldownInterval time.Duration,
+	successCooldownInterval time.Duration,
+	retire func(pool ClientPool, cli *http.Client),
 ) RequestRecorder {
 	failureCounts := make(map[*http.Client]int)
 	lock := new(sync.Mutex)
@@ -47,16 +79,46 @@ func NewRequestRecorderDropClientForContinueFailed(
 			failureCount = 0
 		}
 
-		// create go routine to add client back to pool after cooldown
+		// add client back to pool after cooldown
 		if failureCount < failureThreshold {
 			failureCounts[cli] = failureCount
-			go func() {
-				time.Sleep(cooldownInterval)
-				pool.AddClients(cli)
-			}()
+			addClientsAfter(pool, cooldownInterval, cli)
 		} else {
-			// client is being dropped — clean up its entry to prevent map leak
+			// client is being retired — clean up its entry to prevent map leak
 			delete(failureCounts, cli)
+			retire(pool, cli)
 		}
 	}
 }
+
+// addClientsAfter adds clients to pool once delay has passed, with
+// AddClientsAfter if the pool is a DelayedAdder.
+func addClientsAfter(pool ClientPool, delay time.Duration, clients ...*http.Client) {
+	if adder, ok := pool.(DelayedAdder); ok {
+		adder.AddClientsAfter(delay, clients...)
+		return
+	}
+
+	time.AfterFunc(delay, func() {
+		pool.AddClients(clients...)
+	})
+}
+
+// removeClients removes clients from pool if it is a ClientRemover. Other
+// pools forget a client as soon as it is not added back.
+func removeClients(pool ClientPool, clients ...*http.Client) {
+	if remover, ok := pool.(ClientRemover); ok {
+		remover.RemoveClients(clients...)
+	}
+}
+
+// quarantineClients quarantines clients if pool is a Quarantiner, and
+// removes them otherwise.
+func quarantineClients(pool ClientPool, clients ...*http.Client) {
+	if quarantiner, ok := pool.(Quarantiner); ok {
+		quarantiner.QuarantineClients(clients...)
+		return
+	}
+
+	removeClients(pool, clients...)
+}
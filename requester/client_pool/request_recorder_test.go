@@ -10,7 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func getClients(p *clientPoolImpl) []*http.Client {
+func getClients(p *Pool) []*http.Client {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -37,7 +37,7 @@ func TestNewRequestRecorderAlwaysAddClientBack(t *testing.T) {
 			t.Parallel()
 
 			requestRecorder := NewRequestRecorderAlwaysAddClientBack(tt.cooldownInterval)
-			pool := clientPoolImpl{
+			pool := Pool{
 				mutex:   new(sync.Mutex),
 				cond:    sync.NewCond(new(sync.Mutex)),
 				clients: []*http.Client{},
@@ -109,7 +109,7 @@ func TestNewRequestRecorderDropClientForContinueFailed(t *testing.T) {
 				tt.failureCooldownInterval,
 				tt.successCooldownInterval,
 			)
-			pool := clientPoolImpl{
+			pool := Pool{
 				mutex:   new(sync.Mutex),
 				cond:    sync.NewCond(new(sync.Mutex)),
 				clients: []*http.Client{},
@@ -120,6 +120,59 @@ func TestNewRequestRecorderDropClientForContinueFailed(t *testing.T) {
 			assert.Equal(t, []*http.Client{}, getClients(&pool)) // because of cooldown
 			time.Sleep(tt.wantCooldownInterval)
 			assert.Equal(t, tt.wantClients, getClients(&pool))
+			assert.Equal(t, len(tt.wantClients) == 0, pool.drained)
+		})
+	}
+}
+
+func TestNewRequestRecorderQuarantineClientForContinueFailed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		isRequestFail    goclient.ResultValidator
+		failureThreshold int
+		wantClients      []*http.Client
+		wantQuarantined  []*http.Client
+	}{
+		{
+			name: "happy path: success request",
+			isRequestFail: func(req *http.Request, resp *http.Response, err error) bool {
+				return false
+			},
+			failureThreshold: 1,
+			wantClients:      []*http.Client{http.DefaultClient},
+			wantQuarantined:  []*http.Client{},
+		},
+		{
+			name: "happy path: failed request exceed threshold",
+			isRequestFail: func(req *http.Request, resp *http.Response, err error) bool {
+				return true
+			},
+			failureThreshold: 1,
+			wantClients:      []*http.Client{},
+			wantQuarantined:  []*http.Client{http.DefaultClient},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			requestRecorder := NewRequestRecorderQuarantineClientForContinueFailed(
+				tt.isRequestFail,
+				tt.failureThreshold,
+				10*time.Millisecond,
+				10*time.Millisecond,
+			)
+			pool := NewClientPool()
+			pool.AddClients(http.DefaultClient)
+			cli := pool.GetClient(&http.Request{})
+
+			requestRecorder(pool, cli, nil, nil, nil)
+			time.Sleep(50 * time.Millisecond)
+			assert.Equal(t, tt.wantClients, getClients(pool))
+			assert.Equal(t, tt.wantQuarantined, pool.QuarantinedClients())
 		})
 	}
 }
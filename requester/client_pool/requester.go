@@ -3,6 +3,7 @@ package pool
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/htchan/goclient"
 )
@@ -10,17 +11,60 @@ import (
 func NewClientPoolRequester(
 	pool ClientPool,
 	recordRequest RequestRecorder,
+) goclient.Requester {
+	return NewClientPoolResultRequester(pool, NewResultRecorderFromRequestRecorder(recordRequest))
+}
+
+// NewClientPoolResultRequester works like NewClientPoolRequester, with a
+// recorder receiving the whole request result.
+func NewClientPoolResultRequester(
+	pool ClientPool,
+	recordResult ResultRecorder,
 ) goclient.Requester {
 	return func(req *http.Request) (*http.Response, error) {
-		client := pool.GetClient(req)
+		client, err := acquireClient(pool, req)
+		if err != nil {
+			return nil, fmt.Errorf("acquire client failed: %w", err)
+		}
 
+		start := time.Now()
 		resp, err := client.Do(req)
-		recordRequest(pool, client, req, resp, err)
+		result := RequestResult{
+			Client:   client,
+			Request:  req,
+			Response: resp,
+			Err:      err,
+			Latency:  time.Since(start),
+		}
+		info := clientInfo(pool, client)
+		// the pool ignores the recorder adding back a client removed in the
+		// meantime until the result is reported
+		recordResult(pool, result)
+		if reporter, ok := pool.(ResultReporter); ok {
+			reporter.ReportResult(result)
+		}
 
 		if err != nil {
-			return nil, fmt.Errorf("client do request failed: %w", err)
+			return nil, &ClientError{ClientInfo: info, Err: err}
 		}
 
+		withClientInfo(resp, info)
 		return resp, nil
 	}
 }
+
+// acquireClient takes a client for req out of pool, with AcquireClient if
+// the pool is a ClientAcquirer so that the wait ends with the request
+// context.
+func acquireClient(pool ClientPool, req *http.Request) (*http.Client, error) {
+	if acquirer, ok := pool.(ClientAcquirer); ok {
+		return acquirer.AcquireClient(req.Context(), req)
+	}
+
+	client := pool.GetClient(req)
+	if client == nil {
+		return nil, ErrNoClientAvailable
+	}
+
+	return client, nil
+}
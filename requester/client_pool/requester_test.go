@@ -1,6 +1,7 @@
 package pool
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -96,3 +97,277 @@ func TestNewClientPoolRequester(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClientPoolRequester_AcquireFailed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		addClientsFunc func(pool *Pool)
+		wantErr        error
+	}{
+		{
+			name: "error flow: every client removed",
+			addClientsFunc: func(pool *Pool) {
+				pool.AddClients(http.DefaultClient)
+				pool.RemoveClients(http.DefaultClient)
+			},
+			wantErr: ErrNoClientAvailable,
+		},
+		{
+			name:           "error flow: request context expired",
+			addClientsFunc: func(pool *Pool) {},
+			wantErr:        context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			tt.addClientsFunc(pool)
+
+			requester := NewClientPoolRequester(pool, func(ClientPool, *http.Client, *http.Request, *http.Response, error) {})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := requester(req)
+			assert.Nil(t, resp)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewClientPoolRequester_ClientIdentity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		closed   bool
+		wantInfo bool
+		wantErr  bool
+	}{
+		{
+			name:     "happy flow: response carries the used client",
+			closed:   false,
+			wantInfo: true,
+		},
+		{
+			name:    "error flow: error carries the used client",
+			closed:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer serv.Close()
+			if tt.closed {
+				serv.Close()
+			}
+
+			other, cli := &http.Client{}, &http.Client{}
+			pool := NewClientPool()
+			pool.AddClients(other)
+			pool.ConfigureClient(cli, WithLabel("proxy-b"))
+			pool.AddClients(cli)
+			pool.QuarantineClients(other)
+
+			requester := NewClientPoolRequester(pool, func(_ ClientPool, cli *http.Client, _ *http.Request, _ *http.Response, _ error) {
+				pool.RemoveClients(cli)
+			})
+
+			req, reqErr := http.NewRequest(http.MethodGet, serv.URL, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := requester(req)
+			wantInfo := ClientInfo{Client: cli, Index: 1, Label: "proxy-b"}
+			if tt.wantErr {
+				var clientErr *ClientError
+				assert.ErrorAs(t, err, &clientErr)
+				assert.Equal(t, wantInfo, clientErr.ClientInfo)
+				return
+			}
+
+			assert.NoError(t, err)
+			resp.Body.Close()
+			info, ok := UsedClient(resp)
+			assert.True(t, ok)
+			assert.Equal(t, wantInfo, info)
+		})
+	}
+}
+
+func TestNewClientPoolRequester_RetiredWhileInFlight(t *testing.T) {
+	t.Parallel()
+
+	addBackDirectly := func(pool ClientPool, cli *http.Client, _ *http.Request, _ *http.Response, _ error) {
+		pool.AddClients(cli)
+	}
+	remove := func(pool *Pool) { pool.RemoveClients(http.DefaultClient) }
+	quarantine := func(pool *Pool) { pool.QuarantineClients(http.DefaultClient) }
+
+	tests := []struct {
+		name            string
+		retire          func(pool *Pool)
+		recordRequest   RequestRecorder
+		wantQuarantined int
+		readd           func(pool *Pool)
+	}{
+		{
+			name:          "happy flow: removed, add back without cooldown",
+			retire:        remove,
+			recordRequest: NewRequestRecorderAlwaysAddClientBack(0),
+			readd:         func(pool *Pool) { pool.AddClients(http.DefaultClient) },
+		},
+		{
+			name:          "happy flow: removed, add back after cooldown",
+			retire:        remove,
+			recordRequest: NewRequestRecorderAlwaysAddClientBack(10 * time.Millisecond),
+			readd:         func(pool *Pool) { pool.AddClients(http.DefaultClient) },
+		},
+		{
+			name:          "happy flow: removed, add back directly",
+			retire:        remove,
+			recordRequest: addBackDirectly,
+			readd:         func(pool *Pool) { pool.AddClients(http.DefaultClient) },
+		},
+		{
+			name:            "happy flow: quarantined, add back without cooldown",
+			retire:          quarantine,
+			recordRequest:   NewRequestRecorderAlwaysAddClientBack(0),
+			wantQuarantined: 1,
+			readd:           func(pool *Pool) { pool.ReviveClients(http.DefaultClient) },
+		},
+		{
+			name:            "happy flow: quarantined, add back after cooldown",
+			retire:          quarantine,
+			recordRequest:   NewRequestRecorderAlwaysAddClientBack(10 * time.Millisecond),
+			wantQuarantined: 1,
+			readd:           func(pool *Pool) { pool.ReviveClients(http.DefaultClient) },
+		},
+		{
+			name:            "happy flow: quarantined, add back directly",
+			retire:          quarantine,
+			recordRequest:   addBackDirectly,
+			wantQuarantined: 1,
+			readd:           func(pool *Pool) { pool.ReviveClients(http.DefaultClient) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.retire(pool)
+			}))
+			defer serv.Close()
+
+			pool.AddClients(http.DefaultClient)
+			requester := NewClientPoolRequester(pool, tt.recordRequest)
+
+			req, reqErr := http.NewRequest(http.MethodGet, serv.URL, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := requester(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			time.Sleep(50 * time.Millisecond)
+			stats := pool.Stats()
+			assert.Equal(t, 0, stats.Available)
+			assert.Equal(t, 0, stats.CoolingDown)
+			assert.Equal(t, tt.wantQuarantined, stats.Quarantined)
+			assert.Len(t, stats.Clients, tt.wantQuarantined)
+
+			tt.readd(pool)
+			stats = pool.Stats()
+			assert.Equal(t, 1, stats.Available)
+			assert.Len(t, stats.Clients, 1)
+		})
+	}
+}
+
+// minimalPool implements nothing but ClientPool.
+type minimalPool struct {
+	clients chan *http.Client
+}
+
+func (pool *minimalPool) AddClients(clients ...*http.Client) {
+	for _, cli := range clients {
+		pool.clients <- cli
+	}
+}
+
+func (pool *minimalPool) GetClient(*http.Request) *http.Client {
+	return <-pool.clients
+}
+
+func TestNewClientPoolRequester_MinimalPool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		recordRequest RequestRecorder
+		wantAddedBack bool
+	}{
+		{
+			name:          "happy flow: client added back after cooldown",
+			recordRequest: NewRequestRecorderAlwaysAddClientBack(10 * time.Millisecond),
+			wantAddedBack: true,
+		},
+		{
+			name:          "happy flow: dropped client not added back",
+			recordRequest: NewRequestRecorderDropClientForContinueFailed(func(*http.Request, *http.Response, error) bool { return true }, 1, 0, 0),
+			wantAddedBack: false,
+		},
+		{
+			name:          "happy flow: quarantined client not added back",
+			recordRequest: NewRequestRecorderQuarantineClientForContinueFailed(func(*http.Request, *http.Response, error) bool { return true }, 1, 0, 0),
+			wantAddedBack: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer serv.Close()
+
+			pool := &minimalPool{clients: make(chan *http.Client, 1)}
+			pool.AddClients(serv.Client())
+
+			requester := NewClientPoolRequester(pool, tt.recordRequest)
+
+			req, reqErr := http.NewRequest(http.MethodGet, serv.URL, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := requester(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			info, ok := UsedClient(resp)
+			assert.True(t, ok)
+			assert.Equal(t, ClientInfo{Client: serv.Client(), Index: -1}, info)
+
+			select {
+			case cli := <-pool.clients:
+				assert.True(t, tt.wantAddedBack)
+				assert.Equal(t, serv.Client(), cli)
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, tt.wantAddedBack)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package pool
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/htchan/goclient"
+)
+
+// ResultRecorder is a RequestRecorder receiving the whole request result,
+// including its latency.
+//
+// As with RequestRecorder, the client has been taken out of the pool when
+// the recorder is called. Several recorders of a chain may add it back with
+// a cooldown: a *Pool gives it a single slot after the longest cooldown, and
+// ignores the re-adds if a recorder retires it.
+type ResultRecorder func(pool ClientPool, result RequestResult)
+
+// NewResultRecorderFromRequestRecorder adapts a RequestRecorder so that it
+// can be chained with result recorders.
+func NewResultRecorderFromRequestRecorder(recordRequest RequestRecorder) ResultRecorder {
+	return func(pool ClientPool, result RequestResult) {
+		recordRequest(pool, result.Client, result.Request, result.Response, result.Err)
+	}
+}
+
+// ChainRequestRecorders returns a recorder calling every recorder in order.
+func ChainRequestRecorders(recorders ...RequestRecorder) RequestRecorder {
+	return func(pool ClientPool, cli *http.Client, req *http.Request, resp *http.Response, err error) {
+		for _, recordRequest := range recorders {
+			recordRequest(pool, cli, req, resp, err)
+		}
+	}
+}
+
+// ChainResultRecorders returns a recorder calling every recorder in order.
+func ChainResultRecorders(recorders ...ResultRecorder) ResultRecorder {
+	return func(pool ClientPool, result RequestResult) {
+		for _, recordResult := range recorders {
+			recordResult(pool, result)
+		}
+	}
+}
+
+// NewResultRecorderExponentialCooldown puts the client back into the pool
+// after successCooldownInterval if the request succeeded. Otherwise the
+// cooldown starts at failureCooldownInterval and doubles with every
+// consecutive failure of the client, up to maxCooldownInterval if it is
+// positive.
+// Failure counts of clients that left a ClientStatsReporter pool are
+// forgotten.
+func NewResultRecorderExponentialCooldown(
+	isRequestFail goclient.ResultValidator,
+	failureCooldownInterval time.Duration,
+	maxCooldownInterval time.Duration,
+	successCooldownInterval time.Duration,
+) ResultRecorder {
+	failureCounts := make(map[*http.Client]int)
+	lock := new(sync.Mutex)
+	return func(pool ClientPool, result RequestResult) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if reporter, ok := pool.(ClientStatsReporter); ok {
+			for cli := range failureCounts {
+				if _, ok := reporter.ClientStats(cli); !ok {
+					delete(failureCounts, cli)
+				}
+			}
+		}
+
+		if !isRequestFail(result.Request, result.Response, result.Err) {
+			delete(failureCounts, result.Client)
+			addClientsAfter(pool, successCooldownInterval, result.Client)
+			return
+		}
+
+		failureCounts[result.Client]++
+		cooldown := float64(failureCooldownInterval) * math.Pow(2, float64(failureCounts[result.Client]-1))
+		if maxCooldownInterval > 0 {
+			cooldown = min(cooldown, float64(maxCooldownInterval))
+		} else {
+			// unbounded cooldown still has to fit in a time.Duration
+			cooldown = min(cooldown, math.MaxInt64/2)
+		}
+		addClientsAfter(pool, time.Duration(cooldown), result.Client)
+	}
+}
+
+// NewResultRecorderLatencyPenalty puts the client back into the pool after
+// cooldownInterval, plus penalty for requests slower than latencyThreshold.
+func NewResultRecorderLatencyPenalty(
+	latencyThreshold time.Duration,
+	cooldownInterval time.Duration,
+	penalty time.Duration,
+) ResultRecorder {
+	return func(pool ClientPool, result RequestResult) {
+		cooldown := cooldownInterval
+		if result.Latency >= latencyThreshold {
+			cooldown += penalty
+		}
+		addClientsAfter(pool, cooldown, result.Client)
+	}
+}
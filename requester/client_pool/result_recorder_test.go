@@ -0,0 +1,300 @@
+package pool
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cooldownPool records the cooldowns passed to AddClientsAfter.
+type cooldownPool struct {
+	ClientPool
+	cooldowns []time.Duration
+}
+
+func (pool *cooldownPool) AddClientsAfter(delay time.Duration, clients ...*http.Client) {
+	pool.cooldowns = append(pool.cooldowns, delay)
+}
+
+// memberCooldownPool is a cooldownPool reporting the stats of its members.
+type memberCooldownPool struct {
+	cooldownPool
+	members map[*http.Client]bool
+}
+
+func (pool *memberCooldownPool) ClientStats(cli *http.Client) (ClientStats, bool) {
+	return ClientStats{Client: cli}, pool.members[cli]
+}
+
+func isErr(_ *http.Request, _ *http.Response, err error) bool { return err != nil }
+
+func TestNewResultRecorderFromRequestRecorder(t *testing.T) {
+	t.Parallel()
+
+	result := RequestResult{
+		Client:   &http.Client{},
+		Request:  &http.Request{},
+		Response: &http.Response{},
+		Err:      errors.New("test error"),
+	}
+
+	var got RequestResult
+	recordResult := NewResultRecorderFromRequestRecorder(
+		func(pool ClientPool, cli *http.Client, req *http.Request, resp *http.Response, err error) {
+			got = RequestResult{Client: cli, Request: req, Response: resp, Err: err}
+		},
+	)
+	recordResult(&cooldownPool{}, result)
+
+	assert.Equal(t, result, got)
+}
+
+func TestChainRequestRecorders(t *testing.T) {
+	t.Parallel()
+
+	calls := []string{}
+	record := func(name string) RequestRecorder {
+		return func(pool ClientPool, cli *http.Client, req *http.Request, resp *http.Response, err error) {
+			calls = append(calls, name)
+		}
+	}
+
+	ChainRequestRecorders(record("metrics"), record("add back"))(&cooldownPool{}, nil, nil, nil, nil)
+	assert.Equal(t, []string{"metrics", "add back"}, calls)
+}
+
+func TestChainResultRecorders(t *testing.T) {
+	t.Parallel()
+
+	latencies := []time.Duration{}
+	observe := func(pool ClientPool, result RequestResult) {
+		latencies = append(latencies, result.Latency)
+	}
+
+	pool := &cooldownPool{}
+	recordResult := ChainResultRecorders(
+		observe,
+		NewResultRecorderFromRequestRecorder(NewRequestRecorderAlwaysAddClientBack(time.Second)),
+	)
+	recordResult(pool, RequestResult{Latency: time.Millisecond})
+
+	assert.Equal(t, []time.Duration{time.Millisecond}, latencies)
+	assert.Equal(t, []time.Duration{time.Second}, pool.cooldowns)
+}
+
+func TestNewResultRecorderExponentialCooldown(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		name          string
+		maxCooldown   time.Duration
+		errs          []error
+		wantCooldowns []time.Duration
+	}{
+		{
+			name:          "happy flow: success uses success cooldown",
+			maxCooldown:   time.Minute,
+			errs:          []error{nil, nil},
+			wantCooldowns: []time.Duration{time.Millisecond, time.Millisecond},
+		},
+		{
+			name:        "happy flow: consecutive failures double the cooldown",
+			maxCooldown: time.Minute,
+			errs:        []error{errTest, errTest, errTest},
+			wantCooldowns: []time.Duration{
+				time.Second, 2 * time.Second, 4 * time.Second,
+			},
+		},
+		{
+			name:        "happy flow: success resets the cooldown",
+			maxCooldown: time.Minute,
+			errs:        []error{errTest, errTest, nil, errTest},
+			wantCooldowns: []time.Duration{
+				time.Second, 2 * time.Second, time.Millisecond, time.Second,
+			},
+		},
+		{
+			name:        "happy flow: cooldown capped",
+			maxCooldown: 3 * time.Second,
+			errs:        []error{errTest, errTest, errTest, errTest},
+			wantCooldowns: []time.Duration{
+				time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := &cooldownPool{}
+			cli := &http.Client{}
+			recordResult := NewResultRecorderExponentialCooldown(isErr, time.Second, tt.maxCooldown, time.Millisecond)
+			for _, err := range tt.errs {
+				recordResult(pool, RequestResult{Client: cli, Err: err})
+			}
+
+			assert.Equal(t, tt.wantCooldowns, pool.cooldowns)
+		})
+	}
+}
+
+func TestNewResultRecorderExponentialCooldown_PerClient(t *testing.T) {
+	t.Parallel()
+
+	pool := &cooldownPool{}
+	cli1, cli2 := &http.Client{}, &http.Client{}
+	errTest := errors.New("test error")
+	recordResult := NewResultRecorderExponentialCooldown(isErr, time.Second, 0, time.Millisecond)
+
+	recordResult(pool, RequestResult{Client: cli1, Err: errTest})
+	recordResult(pool, RequestResult{Client: cli1, Err: errTest})
+	recordResult(pool, RequestResult{Client: cli2, Err: errTest})
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, time.Second}, pool.cooldowns)
+}
+
+func TestNewResultRecorderExponentialCooldown_ForgetsDepartedClients(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2 := &http.Client{}, &http.Client{}
+	pool := &memberCooldownPool{members: map[*http.Client]bool{cli1: true, cli2: true}}
+	errTest := errors.New("test error")
+	recordResult := NewResultRecorderExponentialCooldown(isErr, time.Second, 0, time.Millisecond)
+
+	recordResult(pool, RequestResult{Client: cli1, Err: errTest})
+	recordResult(pool, RequestResult{Client: cli2, Err: errTest})
+	// cli1 leaves the pool then joins again
+	delete(pool.members, cli1)
+	recordResult(pool, RequestResult{Client: cli2, Err: errTest})
+	pool.members[cli1] = true
+	recordResult(pool, RequestResult{Client: cli1, Err: errTest})
+
+	assert.Equal(t, []time.Duration{time.Second, time.Second, 2 * time.Second, time.Second}, pool.cooldowns)
+}
+
+func TestChainResultRecorders_AddBackOnce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		recorders   []ResultRecorder
+		wantPending time.Duration
+	}{
+		{
+			name: "happy flow: same cooldown",
+			recorders: []ResultRecorder{
+				NewResultRecorderFromRequestRecorder(NewRequestRecorderAlwaysAddClientBack(50 * time.Millisecond)),
+				NewResultRecorderFromRequestRecorder(NewRequestRecorderAlwaysAddClientBack(50 * time.Millisecond)),
+			},
+			wantPending: 50 * time.Millisecond,
+		},
+		{
+			name: "happy flow: longest cooldown wins",
+			recorders: []ResultRecorder{
+				NewResultRecorderLatencyPenalty(0, 100*time.Millisecond, 50*time.Millisecond),
+				NewResultRecorderFromRequestRecorder(NewRequestRecorderAlwaysAddClientBack(50 * time.Millisecond)),
+			},
+			wantPending: 150 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer serv.Close()
+
+			pool := NewClientPool()
+			pool.AddClients(serv.Client())
+			requester := NewClientPoolResultRequester(pool, ChainResultRecorders(tt.recorders...))
+
+			req, reqErr := http.NewRequest(http.MethodGet, serv.URL, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := requester(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, 1, pool.Stats().CoolingDown)
+			time.Sleep(tt.wantPending - 30*time.Millisecond)
+			assert.Equal(t, 0, pool.Stats().Available)
+
+			time.Sleep(60 * time.Millisecond)
+			stats := pool.Stats()
+			assert.Equal(t, 1, stats.Available)
+			assert.Equal(t, 0, stats.CoolingDown)
+		})
+	}
+}
+
+func TestNewResultRecorderLatencyPenalty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		latency      time.Duration
+		wantCooldown time.Duration
+	}{
+		{
+			name:         "happy flow: fast request",
+			latency:      10 * time.Millisecond,
+			wantCooldown: time.Second,
+		},
+		{
+			name:         "happy flow: slow request is penalised",
+			latency:      time.Second,
+			wantCooldown: 6 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := &cooldownPool{}
+			recordResult := NewResultRecorderLatencyPenalty(500*time.Millisecond, time.Second, 5*time.Second)
+			recordResult(pool, RequestResult{Latency: tt.latency})
+
+			assert.Equal(t, []time.Duration{tt.wantCooldown}, pool.cooldowns)
+		})
+	}
+}
+
+func TestNewClientPoolResultRequester(t *testing.T) {
+	t.Parallel()
+
+	serv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer serv.Close()
+
+	pool := NewClientPool()
+	pool.AddClients(serv.Client())
+
+	var got RequestResult
+	requester := NewClientPoolResultRequester(pool, func(pool ClientPool, result RequestResult) {
+		got = result
+		pool.AddClients(result.Client)
+	})
+
+	req, err := http.NewRequest(http.MethodGet, serv.URL, nil)
+	assert.NoError(t, err)
+	resp, err := requester(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Same(t, serv.Client(), got.Client)
+	assert.Same(t, req, got.Request)
+	assert.Same(t, resp, got.Response)
+	assert.GreaterOrEqual(t, got.Latency, 10*time.Millisecond)
+	assert.Equal(t, []*http.Client{serv.Client()}, getClients(pool))
+}
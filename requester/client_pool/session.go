@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type sessionKeyKey struct{}
+
+// session is a session key pinned to a client.
+type session struct {
+	client *http.Client
+	// expires is when the session is forgotten, zero if it never is.
+	expires time.Time
+}
+
+// WithSession returns a context making every request carrying it use the
+// same client of the pool, e.g. to keep a login and the requests following
+// it on the same proxy and cookie jar. The session is pinned to the client
+// it is first handed out, and re-pinned to another client if that one is
+// removed or quarantined.
+func WithSession(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyKey{}, key)
+}
+
+// SessionKey returns the session key of ctx, if any.
+func SessionKey(ctx context.Context) string {
+	key, _ := ctx.Value(sessionKeyKey{}).(string)
+	return key
+}
+
+// WithSessionTTL forgets sessions that have not been used for ttl. Zero,
+// the default, keeps sessions until their client leaves the pool or the
+// session is ended with EndSession.
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(pool *Pool) {
+		pool.sessionTTL = ttl
+	}
+}
+
+// sessionKey returns the session key of req, if any.
+func sessionKey(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+
+	return SessionKey(req.Context())
+}
+
+// EndSession forgets a session, see WithSession.
+func (pool *Pool) EndSession(key string) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	delete(pool.sessions, key)
+}
+
+// pinned returns the client the session is pinned to, nil if the session is
+// unknown or expired, or its client no longer has tags. Must be called with
+// mutex held.
+func (pool *Pool) pinned(key string, tags []string, now time.Time) *http.Client {
+	if key == "" {
+		return nil
+	}
+
+	pinned, ok := pool.sessions[key]
+	if !ok {
+		return nil
+	}
+	if !pinned.expires.IsZero() && !now.Before(pinned.expires) {
+		delete(pool.sessions, key)
+		return nil
+	}
+	if !pool.hasTags(pinned.client, tags) {
+		return nil
+	}
+
+	return pinned.client
+}
+
+// pin pins the session to cli and extends its TTL. Must be called with mutex
+// held.
+func (pool *Pool) pin(key string, cli *http.Client, now time.Time) {
+	if key == "" {
+		return
+	}
+	if pool.sessions == nil {
+		pool.sessions = make(map[string]*session)
+	}
+
+	if _, ok := pool.sessions[key]; !ok {
+		pool.expireSessions(now)
+	}
+
+	pinned := &session{client: cli}
+	if pool.sessionTTL > 0 {
+		pinned.expires = now.Add(pool.sessionTTL)
+	}
+	pool.sessions[key] = pinned
+}
+
+// unpin forgets the sessions pinned to the clients and wakes up their
+// waiters so that they are re-pinned. Must be called with mutex held.
+func (pool *Pool) unpin(clients []*http.Client) {
+	unpinned := false
+	for key, pinned := range pool.sessions {
+		if slices.Contains(clients, pinned.client) {
+			delete(pool.sessions, key)
+			unpinned = true
+		}
+	}
+
+	if unpinned {
+		pool.cond.Broadcast()
+	}
+}
+
+// expireSessions forgets expired sessions. Must be called with mutex held.
+func (pool *Pool) expireSessions(now time.Time) {
+	for key, pinned := range pool.sessions {
+		if !pinned.expires.IsZero() && !now.Before(pinned.expires) {
+			delete(pool.sessions, key)
+		}
+	}
+}
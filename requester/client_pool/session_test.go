@@ -0,0 +1,141 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sessionRequest(t *testing.T, key string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(WithSession(context.Background(), key), http.MethodGet, "https://example.com", nil)
+	assert.NoError(t, err)
+
+	return req
+}
+
+func TestSessionKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", SessionKey(context.Background()))
+	assert.Equal(t, "user-1", SessionKey(WithSession(context.Background(), "user-1")))
+}
+
+func TestPool_AcquireClient_Session(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []Option
+		// afterPin runs once the session has been pinned to the first client
+		// and the client has been put back after the second one.
+		afterPin func(pool *Pool, first *http.Client)
+		wantSame bool
+	}{
+		{
+			name:     "happy flow: session sticks to its client",
+			afterPin: func(pool *Pool, first *http.Client) {},
+			wantSame: true,
+		},
+		{
+			name: "happy flow: session re-pinned when its client is removed",
+			afterPin: func(pool *Pool, first *http.Client) {
+				pool.RemoveClients(first)
+			},
+			wantSame: false,
+		},
+		{
+			name: "happy flow: session re-pinned when its client is quarantined",
+			afterPin: func(pool *Pool, first *http.Client) {
+				pool.QuarantineClients(first)
+			},
+			wantSame: false,
+		},
+		{
+			name: "happy flow: ended session re-pinned",
+			afterPin: func(pool *Pool, first *http.Client) {
+				pool.EndSession("user-1")
+			},
+			wantSame: false,
+		},
+		{
+			name: "happy flow: expired session re-pinned",
+			opts: []Option{WithSessionTTL(20 * time.Millisecond)},
+			afterPin: func(pool *Pool, first *http.Client) {
+				time.Sleep(30 * time.Millisecond)
+			},
+			wantSame: false,
+		},
+		{
+			name: "happy flow: session in use within TTL sticks",
+			opts: []Option{WithSessionTTL(time.Minute)},
+			afterPin: func(pool *Pool, first *http.Client) {
+				time.Sleep(10 * time.Millisecond)
+			},
+			wantSame: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cli1, cli2 := &http.Client{}, &http.Client{}
+			pool := NewClientPool(tt.opts...)
+			pool.AddClients(cli1, cli2)
+
+			first, err := pool.AcquireClient(context.Background(), sessionRequest(t, "user-1"))
+			assert.NoError(t, err)
+			assert.Same(t, cli1, first)
+			// put it back behind the other client so that FIFO would not
+			// pick it first
+			pool.AddClients(first)
+			assert.Equal(t, 1, pool.Stats().Sessions)
+
+			tt.afterPin(pool, first)
+
+			second, err := pool.AcquireClient(context.Background(), sessionRequest(t, "user-1"))
+			assert.NoError(t, err)
+			if tt.wantSame {
+				assert.Same(t, first, second)
+			} else {
+				assert.Same(t, cli2, second)
+			}
+			assert.Equal(t, 1, pool.Stats().Sessions)
+		})
+	}
+}
+
+func TestPool_AcquireClient_SessionWaitsForItsClient(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2 := &http.Client{}, &http.Client{}
+	pool := NewClientPool()
+	pool.AddClients(cli1, cli2)
+
+	first, err := pool.AcquireClient(context.Background(), sessionRequest(t, "user-1"))
+	assert.NoError(t, err)
+	assert.Same(t, cli1, first)
+
+	done := make(chan *http.Client)
+	go func() {
+		cli, _ := pool.AcquireClient(context.Background(), sessionRequest(t, "user-1"))
+		done <- cli
+	}()
+
+	// cli2 is available, but the session waits for cli1
+	assert.Eventually(t, func() bool { return pool.Stats().Waiters == 1 }, time.Second, time.Millisecond)
+
+	other, err := pool.AcquireClient(context.Background(), sessionRequest(t, "user-2"))
+	assert.NoError(t, err)
+	assert.Same(t, cli2, other)
+
+	// dropping the busy client re-pins the waiting session
+	pool.AddClients(other)
+	pool.RemoveClients(cli1)
+	assert.Same(t, cli2, <-done)
+}
@@ -0,0 +1,118 @@
+package pool
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// PoolStats is a point-in-time view of a client pool.
+type PoolStats struct {
+	// Available is the number of client slots ready to be acquired.
+	Available int
+	// InUse is the number of requests in flight with clients of the pool.
+	InUse int
+	// CoolingDown is the number of clients waiting to be added back.
+	CoolingDown int
+	// Quarantined is the number of clients out of rotation until revived.
+	Quarantined int
+	// Dropped is the number of clients removed since the pool was created.
+	Dropped int
+	// Waiters is the number of callers waiting for a client.
+	Waiters int
+	// Sessions is the number of sessions pinned to a client.
+	Sessions int
+	// Clients holds the statistics of every client, in join order.
+	Clients []ClientStats
+}
+
+// ClientStats holds the statistics of a single pooled client.
+type ClientStats struct {
+	Client *http.Client
+	// Index is the order in which the client joined the pool.
+	Index       int
+	Label       string
+	Tags        []string
+	Quarantined bool
+
+	Requests            uint64
+	Failures            uint64
+	ConsecutiveFailures int
+	InFlight            int
+	AverageLatency      time.Duration
+	// LastError is the error of the last failed request, which is nil if
+	// the failure was determined from the response.
+	LastError error
+}
+
+// Stats returns pool-level and per-client statistics.
+func (pool *Pool) Stats() PoolStats {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	stats := PoolStats{
+		Available:   len(pool.clients),
+		InUse:       pool.inFlight(),
+		CoolingDown: len(pool.pending),
+		Quarantined: len(pool.quarantined),
+		Dropped:     pool.dropped,
+		Waiters:     pool.waiters,
+		Sessions:    len(pool.sessions),
+		Clients:     make([]ClientStats, 0, len(pool.members)+len(pool.quarantined)),
+	}
+
+	for cli, state := range pool.members {
+		stats.Clients = append(stats.Clients, state.stats(cli, pool.config(cli), false))
+	}
+	for cli, state := range pool.quarantined {
+		stats.Clients = append(stats.Clients, state.stats(cli, pool.config(cli), true))
+	}
+	slices.SortFunc(stats.Clients, func(a, b ClientStats) int { return a.Index - b.Index })
+
+	return stats
+}
+
+// ClientStats returns the statistics of a single client, and false if the
+// client does not belong to the pool.
+func (pool *Pool) ClientStats(cli *http.Client) (ClientStats, bool) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if state, ok := pool.members[cli]; ok {
+		return state.stats(cli, pool.config(cli), false), true
+	}
+	if state, ok := pool.quarantined[cli]; ok {
+		return state.stats(cli, pool.config(cli), true), true
+	}
+
+	return ClientStats{}, false
+}
+
+// failed reports whether a request result counts as a failure.
+func (pool *Pool) failed(result RequestResult) bool {
+	if pool.isFailure == nil {
+		return result.Err != nil
+	}
+
+	return pool.isFailure(result.Request, result.Response, result.Err)
+}
+
+func (state *clientState) stats(cli *http.Client, config clientConfig, quarantined bool) ClientStats {
+	stats := ClientStats{
+		Client:              cli,
+		Index:               state.index,
+		Label:               config.label,
+		Tags:                slices.Clone(config.tags),
+		Quarantined:         quarantined,
+		Requests:            state.requests,
+		Failures:            state.failures,
+		ConsecutiveFailures: state.consecutiveFailures,
+		InFlight:            state.inFlight,
+		LastError:           state.lastError,
+	}
+	if state.requests > 0 {
+		stats.AverageLatency = state.totalLatency / time.Duration(state.requests)
+	}
+
+	return stats
+}
@@ -0,0 +1,205 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_Stats(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+	cli1, cli2, cli3, cli4 := &http.Client{}, &http.Client{}, &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		prepare func(pool *Pool)
+		want    PoolStats
+	}{
+		{
+			name:    "happy flow: empty pool",
+			prepare: func(pool *Pool) {},
+			want:    PoolStats{Clients: []ClientStats{}},
+		},
+		{
+			name: "happy flow: pool and client stats",
+			prepare: func(pool *Pool) {
+				pool.AddClients(cli1, cli2, cli3, cli4)
+
+				acquired, _ := pool.AcquireClient(context.Background(), &http.Request{})
+				pool.ReportResult(RequestResult{Client: acquired, Latency: 10 * time.Millisecond})
+				pool.ReportResult(RequestResult{Client: acquired, Err: errTest, Latency: 30 * time.Millisecond})
+				pool.AcquireClient(context.Background(), &http.Request{})
+
+				pool.QuarantineClients(cli3)
+				pool.RemoveClients(cli4)
+				pool.AddClientsAfter(time.Hour, cli1)
+			},
+			want: PoolStats{
+				Available:   0,
+				InUse:       1,
+				CoolingDown: 1,
+				Quarantined: 1,
+				Dropped:     1,
+				Clients: []ClientStats{
+					{
+						Client:              cli1,
+						Index:               0,
+						Requests:            2,
+						Failures:            1,
+						ConsecutiveFailures: 1,
+						AverageLatency:      20 * time.Millisecond,
+						LastError:           errTest,
+					},
+					{Client: cli2, Index: 1, InFlight: 1},
+					{Client: cli3, Index: 2, Quarantined: true},
+				},
+			},
+		},
+		{
+			name: "happy flow: success resets consecutive failures",
+			prepare: func(pool *Pool) {
+				pool.AddClients(cli1)
+				pool.ReportResult(RequestResult{Client: cli1, Err: errTest})
+				pool.ReportResult(RequestResult{Client: cli1})
+			},
+			want: PoolStats{
+				Available: 1,
+				Clients: []ClientStats{
+					{Client: cli1, Requests: 2, Failures: 1, LastError: errTest},
+				},
+			},
+		},
+		{
+			name: "happy flow: custom failure validator",
+			opts: []Option{
+				WithFailureValidator(func(_ *http.Request, resp *http.Response, err error) bool {
+					return err != nil || resp.StatusCode >= http.StatusInternalServerError
+				}),
+			},
+			prepare: func(pool *Pool) {
+				pool.AddClients(cli1)
+				pool.ReportResult(RequestResult{
+					Client:   cli1,
+					Response: &http.Response{StatusCode: http.StatusBadGateway},
+				})
+			},
+			want: PoolStats{
+				Available: 1,
+				Clients: []ClientStats{
+					{Client: cli1, Requests: 1, Failures: 1, ConsecutiveFailures: 1},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool(tt.opts...)
+			tt.prepare(pool)
+
+			assert.Equal(t, tt.want, pool.Stats())
+
+			// stop pending re-adds without waiting for in-flight requests
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			pool.Close(ctx)
+		})
+	}
+}
+
+func TestPool_Stats_Waiters(t *testing.T) {
+	t.Parallel()
+
+	pool := NewClientPool()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.AcquireClient(ctx, &http.Request{})
+	}()
+
+	assert.Eventually(t, func() bool { return pool.Stats().Waiters == 1 }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Equal(t, 0, pool.Stats().Waiters)
+}
+
+func TestPool_ClientStats(t *testing.T) {
+	t.Parallel()
+
+	cli1, cli2 := &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name   string
+		cli    *http.Client
+		want   ClientStats
+		wantOK bool
+	}{
+		{
+			name:   "happy flow: pooled client",
+			cli:    cli1,
+			want:   ClientStats{Client: cli1, Requests: 1, AverageLatency: time.Millisecond},
+			wantOK: true,
+		},
+		{
+			name:   "happy flow: quarantined client",
+			cli:    cli2,
+			want:   ClientStats{Client: cli2, Index: 1, Quarantined: true},
+			wantOK: true,
+		},
+		{
+			name:   "error flow: unknown client",
+			cli:    &http.Client{},
+			want:   ClientStats{},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.AddClients(cli1, cli2)
+			pool.QuarantineClients(cli2)
+			pool.ReportResult(RequestResult{Client: cli1, Latency: time.Millisecond})
+
+			stats, ok := pool.ClientStats(tt.cli)
+			assert.Equal(t, tt.want, stats)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestPool_ClientStats_TagsCopied(t *testing.T) {
+	t.Parallel()
+
+	cli := &http.Client{}
+	pool := NewClientPool()
+	pool.ConfigureClient(cli, WithTags("eu", "residential"))
+	pool.AddClients(cli)
+
+	stats, ok := pool.ClientStats(cli)
+	assert.True(t, ok)
+	stats.Tags[0] = "us"
+
+	// the pool keeps its own tags
+	stats, _ = pool.ClientStats(cli)
+	assert.Equal(t, []string{"eu", "residential"}, stats.Tags)
+
+	req, err := http.NewRequestWithContext(WithRequiredTags(context.Background(), "eu"), http.MethodGet, "https://example.com", nil)
+	assert.NoError(t, err)
+	acquired, err := pool.AcquireClient(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Same(t, cli, acquired)
+}
@@ -0,0 +1,170 @@
+package pool
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Candidate describes an available client offered to a Strategy.
+type Candidate struct {
+	Client *http.Client
+	// Index is the order in which the client joined the pool.
+	Index int
+	// Label is the label set with WithLabel, if any.
+	Label string
+	// InFlight is the number of requests currently made with the client.
+	InFlight int
+	// Latency is a moving average of the client's recent request latency,
+	// zero if it has not completed a request yet.
+	Latency time.Duration
+}
+
+// Strategy picks a client for req and returns its index in candidates.
+// candidates is never empty and lists clients in the order they became
+// available. An out of range index falls back to the first candidate.
+type Strategy func(req *http.Request, candidates []Candidate) int
+
+// KeyFunc returns the key used to route a request.
+type KeyFunc func(req *http.Request) string
+
+// HostKey routes requests by their host.
+func HostKey(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	return req.URL.Host
+}
+
+// NewStrategyRoundRobin cycles through clients in the order they joined the
+// pool, skipping the ones that are not available.
+func NewStrategyRoundRobin() Strategy {
+	var (
+		lock sync.Mutex
+		last = -1
+	)
+	return func(_ *http.Request, candidates []Candidate) int {
+		lock.Lock()
+		defer lock.Unlock()
+
+		next, first := -1, 0
+		for i, candidate := range candidates {
+			if candidate.Index < candidates[first].Index {
+				first = i
+			}
+			if candidate.Index > last && (next == -1 || candidate.Index < candidates[next].Index) {
+				next = i
+			}
+		}
+		if next == -1 {
+			next = first
+		}
+
+		last = candidates[next].Index
+		return next
+	}
+}
+
+// NewStrategyRandom picks an available client at random.
+func NewStrategyRandom() Strategy {
+	return func(_ *http.Request, candidates []Candidate) int {
+		return rand.IntN(len(candidates))
+	}
+}
+
+// NewStrategyWeighted picks an available client at random, in proportion to
+// its weight. Clients missing from weights have a weight of 1, and clients
+// with a weight of 0 or less are only picked if nothing else is available.
+func NewStrategyWeighted(weights map[*http.Client]int) Strategy {
+	weightOf := func(cli *http.Client) int {
+		weight, ok := weights[cli]
+		if !ok {
+			return 1
+		}
+		return max(weight, 0)
+	}
+
+	return func(_ *http.Request, candidates []Candidate) int {
+		total := 0
+		for _, candidate := range candidates {
+			total += weightOf(candidate.Client)
+		}
+		if total == 0 {
+			return 0
+		}
+
+		n := rand.IntN(total)
+		for i, candidate := range candidates {
+			n -= weightOf(candidate.Client)
+			if n < 0 {
+				return i
+			}
+		}
+
+		return 0
+	}
+}
+
+// NewStrategyLeastInFlight picks the available client with the fewest
+// requests in flight. It only differs from FIFO for clients allowed to serve
+// concurrent requests with WithMaxConcurrency, or added to the pool more than
+// once.
+func NewStrategyLeastInFlight() Strategy {
+	return func(_ *http.Request, candidates []Candidate) int {
+		picked := 0
+		for i, candidate := range candidates {
+			if candidate.InFlight < candidates[picked].InFlight {
+				picked = i
+			}
+		}
+		return picked
+	}
+}
+
+// NewStrategyLeastLatency picks the available client with the lowest recent
+// latency. Clients without any completed request are tried first.
+func NewStrategyLeastLatency() Strategy {
+	return func(_ *http.Request, candidates []Candidate) int {
+		picked := 0
+		for i, candidate := range candidates {
+			if candidate.Latency < candidates[picked].Latency {
+				picked = i
+			}
+		}
+		return picked
+	}
+}
+
+// NewStrategyConsistentHash routes requests with the same key to the same
+// client, using rendezvous hashing so that only the keys of a departed client
+// move elsewhere. key defaults to HostKey.
+//
+// Like every strategy, it only chooses among the available clients: while
+// the key's client is at its concurrency limit or cooling down, requests go
+// to the next client in the key's ranking instead of waiting for it. Use
+// WithSession to pin requests to a client strictly.
+func NewStrategyConsistentHash(key KeyFunc) Strategy {
+	if key == nil {
+		key = HostKey
+	}
+
+	return func(req *http.Request, candidates []Candidate) int {
+		k := key(req)
+
+		picked, best := 0, uint64(0)
+		for i, candidate := range candidates {
+			hash := fnv.New64a()
+			hash.Write([]byte(k))
+			hash.Write([]byte{0})
+			hash.Write([]byte(strconv.Itoa(candidate.Index)))
+
+			if score := hash.Sum64(); i == 0 || score > best {
+				picked, best = i, score
+			}
+		}
+		return picked
+	}
+}
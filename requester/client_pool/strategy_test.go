@@ -0,0 +1,233 @@
+package pool
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{name: "request host", req: &http.Request{URL: &url.URL{Host: "example.com"}}, want: "example.com"},
+		{name: "nil url", req: &http.Request{}, want: ""},
+		{name: "nil request", req: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, HostKey(tt.req))
+		})
+	}
+}
+
+func TestNewStrategyRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		candidates [][]Candidate
+		wantPicks  []int
+	}{
+		{
+			name: "happy flow: cycles through clients by join order",
+			candidates: [][]Candidate{
+				{{Index: 1}, {Index: 0}, {Index: 2}},
+				{{Index: 1}, {Index: 0}, {Index: 2}},
+				{{Index: 1}, {Index: 0}, {Index: 2}},
+				{{Index: 1}, {Index: 0}, {Index: 2}},
+			},
+			wantPicks: []int{1, 0, 2, 1},
+		},
+		{
+			name: "edge case: skips unavailable clients",
+			candidates: [][]Candidate{
+				{{Index: 0}, {Index: 2}},
+				{{Index: 0}, {Index: 1}},
+				{{Index: 0}, {Index: 1}},
+			},
+			wantPicks: []int{0, 1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			strategy := NewStrategyRoundRobin()
+			for i, candidates := range tt.candidates {
+				assert.Equal(t, tt.wantPicks[i], strategy(nil, candidates))
+			}
+		})
+	}
+}
+
+func TestNewStrategyRandom(t *testing.T) {
+	t.Parallel()
+
+	strategy := NewStrategyRandom()
+	candidates := []Candidate{{Index: 0}, {Index: 1}, {Index: 2}}
+
+	picked := make(map[int]bool)
+	for range 200 {
+		i := strategy(nil, candidates)
+		assert.GreaterOrEqual(t, i, 0)
+		assert.Less(t, i, len(candidates))
+		picked[i] = true
+	}
+	assert.Len(t, picked, len(candidates))
+}
+
+func TestNewStrategyWeighted(t *testing.T) {
+	t.Parallel()
+
+	heavy, light, disabled, unknown := &http.Client{}, &http.Client{}, &http.Client{}, &http.Client{}
+	weights := map[*http.Client]int{heavy: 9, light: 1, disabled: 0}
+
+	tests := []struct {
+		name        string
+		candidates  []Candidate
+		wantAllowed []int
+		wantMost    int
+	}{
+		{
+			name:        "happy flow: favours heavier clients",
+			candidates:  []Candidate{{Client: light}, {Client: heavy}},
+			wantAllowed: []int{0, 1},
+			wantMost:    1,
+		},
+		{
+			name:        "happy flow: zero weight is never picked",
+			candidates:  []Candidate{{Client: disabled}, {Client: unknown}},
+			wantAllowed: []int{1},
+			wantMost:    1,
+		},
+		{
+			name:        "edge case: only zero weights falls back to first",
+			candidates:  []Candidate{{Client: disabled}},
+			wantAllowed: []int{0},
+			wantMost:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			strategy := NewStrategyWeighted(weights)
+			counts := make(map[int]int)
+			for range 500 {
+				i := strategy(nil, tt.candidates)
+				assert.Contains(t, tt.wantAllowed, i)
+				counts[i]++
+			}
+			for i, count := range counts {
+				assert.LessOrEqual(t, count, counts[tt.wantMost], "index %d", i)
+			}
+		})
+	}
+}
+
+func TestNewStrategyLeastInFlight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       int
+	}{
+		{
+			name:       "happy flow: picks least busy client",
+			candidates: []Candidate{{InFlight: 3}, {InFlight: 1}, {InFlight: 2}},
+			want:       1,
+		},
+		{
+			name:       "edge case: ties keep FIFO order",
+			candidates: []Candidate{{InFlight: 1}, {InFlight: 1}},
+			want:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, NewStrategyLeastInFlight()(nil, tt.candidates))
+		})
+	}
+}
+
+func TestNewStrategyLeastLatency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       int
+	}{
+		{
+			name:       "happy flow: picks fastest client",
+			candidates: []Candidate{{Latency: time.Second}, {Latency: time.Millisecond}, {Latency: time.Minute}},
+			want:       1,
+		},
+		{
+			name:       "happy flow: untried client goes first",
+			candidates: []Candidate{{Latency: time.Millisecond}, {Latency: 0}},
+			want:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, NewStrategyLeastLatency()(nil, tt.candidates))
+		})
+	}
+}
+
+func TestNewStrategyConsistentHash(t *testing.T) {
+	t.Parallel()
+
+	strategy := NewStrategyConsistentHash(nil)
+	candidates := []Candidate{{Index: 0}, {Index: 1}, {Index: 2}, {Index: 3}}
+	reqFor := func(host string) *http.Request { return &http.Request{URL: &url.URL{Host: host}} }
+
+	// same key sticks to the same client
+	first := strategy(reqFor("a.example.com"), candidates)
+	for range 10 {
+		assert.Equal(t, first, strategy(reqFor("a.example.com"), candidates))
+	}
+
+	// removing another client does not move the key
+	var remaining []Candidate
+	for i, candidate := range candidates {
+		if i != (first+1)%len(candidates) {
+			remaining = append(remaining, candidate)
+		}
+	}
+	picked := strategy(reqFor("a.example.com"), remaining)
+	assert.Equal(t, candidates[first].Index, remaining[picked].Index)
+
+	// keys spread over clients
+	seen := make(map[int]bool)
+	for _, host := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		seen[strategy(reqFor(host), candidates)] = true
+	}
+	assert.Greater(t, len(seen), 1)
+
+	// custom key
+	byMethod := NewStrategyConsistentHash(func(req *http.Request) string { return req.Method })
+	get := byMethod(&http.Request{Method: http.MethodGet}, candidates)
+	assert.Equal(t, get, byMethod(&http.Request{Method: http.MethodGet, URL: &url.URL{Host: "x"}}, candidates))
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+type requiredTagsKey struct{}
+
+// WithTags tags a client, e.g. with its region, proxy type or API key tier,
+// so that requests can ask for clients with given tags, see
+// WithRequiredTags.
+func WithTags(tags ...string) ClientOption {
+	return func(config *clientConfig) {
+		config.tags = slices.Compact(slices.Sorted(slices.Values(tags)))
+	}
+}
+
+// WithRequiredTags returns a context making the pool hand out only clients
+// having every one of the tags to requests carrying it. Tags add up with the
+// ones already required by ctx.
+func WithRequiredTags(ctx context.Context, tags ...string) context.Context {
+	required := append(slices.Clone(RequiredTags(ctx)), tags...)
+	return context.WithValue(ctx, requiredTagsKey{}, slices.Compact(slices.Sorted(slices.Values(required))))
+}
+
+// RequiredTags returns the tags required by ctx.
+func RequiredTags(ctx context.Context) []string {
+	tags, _ := ctx.Value(requiredTagsKey{}).([]string)
+	return tags
+}
+
+// requiredTags returns the tags required by req, if any.
+func requiredTags(req *http.Request) []string {
+	if req == nil {
+		return nil
+	}
+
+	return RequiredTags(req.Context())
+}
+
+// hasTags reports whether the client has every tag. Must be called with
+// mutex held.
+func (pool *Pool) hasTags(cli *http.Client, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+
+	config, ok := pool.configs[cli]
+	if !ok {
+		return false
+	}
+	for _, tag := range tags {
+		if !slices.Contains(config.tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyHasTags reports whether a client of the pool, available or not, has
+// every tag. Must be called with mutex held.
+func (pool *Pool) anyHasTags(tags []string) bool {
+	for cli := range pool.members {
+		if pool.hasTags(cli, tags) {
+			return true
+		}
+	}
+	for cli := range pool.quarantined {
+		if pool.hasTags(cli, tags) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequiredTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		want []string
+	}{
+		{
+			name: "happy flow: no tags",
+			ctx:  context.Background,
+			want: nil,
+		},
+		{
+			name: "happy flow: tags sorted and deduplicated",
+			ctx: func() context.Context {
+				return WithRequiredTags(context.Background(), "region=eu", "type=residential", "region=eu")
+			},
+			want: []string{"region=eu", "type=residential"},
+		},
+		{
+			name: "happy flow: tags add up",
+			ctx: func() context.Context {
+				ctx := WithRequiredTags(context.Background(), "region=eu")
+				return WithRequiredTags(ctx, "tier=premium")
+			},
+			want: []string{"region=eu", "tier=premium"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, RequiredTags(tt.ctx()))
+		})
+	}
+}
+
+func TestPool_AcquireClient_Tags(t *testing.T) {
+	t.Parallel()
+
+	euClient, usClient, untagged := &http.Client{}, &http.Client{}, &http.Client{}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    *http.Client
+		wantErr bool
+	}{
+		{
+			name: "happy flow: no required tags takes the first client",
+			tags: nil,
+			want: untagged,
+		},
+		{
+			name: "happy flow: matching client",
+			tags: []string{"region=eu"},
+			want: euClient,
+		},
+		{
+			name: "happy flow: client must have every tag",
+			tags: []string{"region=us", "type=datacenter"},
+			want: usClient,
+		},
+		{
+			name:    "error flow: no client has the tags",
+			tags:    []string{"region=eu", "type=datacenter"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pool := NewClientPool()
+			pool.ConfigureClient(euClient, WithTags("region=eu", "type=residential"))
+			pool.ConfigureClient(usClient, WithTags("type=datacenter", "region=us"))
+			pool.AddClients(untagged, euClient, usClient)
+
+			req, err := http.NewRequestWithContext(
+				WithRequiredTags(context.Background(), tt.tags...), http.MethodGet, "https://example.com", nil)
+			assert.NoError(t, err)
+
+			cli, err := pool.AcquireClient(context.Background(), req)
+			assert.Same(t, tt.want, cli)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNoClientAvailable)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPool_AcquireClient_WaitsForTaggedClient(t *testing.T) {
+	t.Parallel()
+
+	euClient, other := &http.Client{}, &http.Client{}
+	pool := NewClientPool()
+	pool.ConfigureClient(euClient, WithTags("region=eu"))
+	pool.AddClients(euClient, other)
+
+	req, err := http.NewRequestWithContext(
+		WithRequiredTags(context.Background(), "region=eu"), http.MethodGet, "https://example.com", nil)
+	assert.NoError(t, err)
+
+	first, err := pool.AcquireClient(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Same(t, euClient, first)
+
+	done := make(chan *http.Client)
+	go func() {
+		cli, _ := pool.AcquireClient(context.Background(), req)
+		done <- cli
+	}()
+
+	// the untagged client is available but must not be handed out
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, pool.Stats().Waiters)
+
+	pool.AddClients(euClient)
+	assert.Same(t, euClient, <-done)
+}
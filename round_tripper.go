@@ -0,0 +1,55 @@
+package goclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNilResponse is returned by the RoundTripper of a client whose chain
+// returned neither a response nor an error.
+var ErrNilResponse = errors.New("requester returned no response and no error")
+
+type roundTripper struct {
+	client *Client
+}
+
+// RoundTripper adapts the client to http.RoundTripper, so that its whole
+// middleware chain can be injected into libraries accepting one.
+func (c *Client) RoundTripper() http.RoundTripper {
+	return &roundTripper{client: c}
+}
+
+// HTTPClient returns an *http.Client sending its requests through the
+// client. Redirects are followed by the returned client if the client's
+// requester does not follow them itself.
+func (c *Client) HTTPClient() *http.Client {
+	return &http.Client{Transport: c.RoundTripper()}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, while middlewares such as
+	// retry replace its body
+	resp, err := rt.client.Do(req.Clone(req.Context()))
+	if err != nil {
+		// a RoundTripper returns either a response or an error
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	if resp == nil {
+		return nil, ErrNilResponse
+	}
+
+	return resp, nil
+}
+
+// RoundTripperRequester sends requests with rt, http.DefaultTransport if nil.
+// Unlike an http.Client, it neither follows redirects nor handles cookies.
+func RoundTripperRequester(rt http.RoundTripper) Requester {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return rt.RoundTrip
+}
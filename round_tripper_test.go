@@ -0,0 +1,163 @@
+package goclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	testErr := errors.New("test error")
+
+	tests := []struct {
+		name       string
+		requester  Requester
+		wantStatus int
+		wantErr    error
+	}{
+		{
+			name: "happy flow: response from the chain",
+			requester: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusAccepted, Request: req}, nil
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "error flow: error from the chain",
+			requester: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusBadGateway}, testErr
+			},
+			wantErr: testErr,
+		},
+		{
+			name: "error flow: no response and no error",
+			requester: func(req *http.Request) (*http.Response, error) {
+				return nil, nil
+			},
+			wantErr: ErrNilResponse,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			client := NewClient(
+				WithRequester(test.requester),
+				WithMiddlewares(func(f Requester) Requester {
+					return func(req *http.Request) (*http.Response, error) {
+						calls++
+						req.Header.Set("X-Middleware", "applied")
+						return f(req)
+					}
+				}),
+			)
+
+			req, reqErr := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := client.RoundTripper().RoundTrip(req)
+			assert.Equal(t, 1, calls)
+			// the caller's request is left untouched
+			assert.Empty(t, req.Header.Get("X-Middleware"))
+			if test.wantErr != nil {
+				assert.Nil(t, resp)
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestClientHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Middleware")))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithRequester(RoundTripperRequester(server.Client().Transport)),
+		WithMiddlewares(func(f Requester) Requester {
+			return func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Middleware", "applied")
+				return f(req)
+			}
+		}),
+	)
+
+	resp, err := client.HTTPClient().Get(server.URL + "/old")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/new applied", string(body))
+}
+
+func TestRoundTripperRequester(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		rt         func(server *httptest.Server) http.RoundTripper
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "happy flow: custom round tripper",
+			rt:         func(server *httptest.Server) http.RoundTripper { return server.Client().Transport },
+			path:       "/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "happy flow: default transport",
+			rt:         func(server *httptest.Server) http.RoundTripper { return nil },
+			path:       "/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "happy flow: redirects are not followed",
+			rt:         func(server *httptest.Server) http.RoundTripper { return nil },
+			path:       "/old",
+			wantStatus: http.StatusFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/old" {
+					http.Redirect(w, r, "/new", http.StatusFound)
+				}
+			}))
+			defer server.Close()
+
+			req, reqErr := http.NewRequest(http.MethodGet, server.URL+test.path, nil)
+			assert.NoError(t, reqErr)
+
+			resp, err := RoundTripperRequester(test.rt(server))(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, test.wantStatus, resp.StatusCode)
+		})
+	}
+}